@@ -0,0 +1,62 @@
+package locerr
+
+import (
+	"fmt"
+	"io"
+)
+
+// AnnotationKind represents the kind of an annotation stacked on an error.
+type AnnotationKind int
+
+const (
+	// AnnotationNote is a kind for additional information of the error. This is the default kind.
+	AnnotationNote AnnotationKind = iota
+	// AnnotationHelp is a kind for advice to fix the error.
+	AnnotationHelp
+)
+
+// String returns the name of the kind in lower case such as "note" or "help".
+func (k AnnotationKind) String() string {
+	switch k {
+	case AnnotationNote:
+		return "note"
+	case AnnotationHelp:
+		return "help"
+	default:
+		return fmt.Sprintf("annotation(%d)", int(k))
+	}
+}
+
+// Label returns the label of the kind shown in error message such as "Note".
+func (k AnnotationKind) Label() string {
+	if k == AnnotationHelp {
+		return "Help"
+	}
+	return "Note"
+}
+
+// Annotation is an additional message stacked on an error. It may have its own position or range.
+type Annotation struct {
+	// Kind of the annotation.
+	Kind AnnotationKind
+	// Message of the annotation.
+	Message string
+	// Start position of the annotation. Its File is nil when the annotation has no position.
+	Start Pos
+	// End position of the annotation. Its File is nil when the annotation has no range.
+	End Pos
+}
+
+// HasPos returns whether the annotation has its own position.
+func (a *Annotation) HasPos() bool {
+	return a.Start.File != nil
+}
+
+func (a *Annotation) writeMessage(w io.Writer) {
+	// {Label}: {msg} (at {pos})
+	green.Fprintf(w, "\n  %s: ", a.Kind.Label())
+	fmt.Fprint(w, a.Message)
+	if a.HasPos() {
+		gray.Fprintf(w, " (at %s)", a.Start.String())
+	}
+}
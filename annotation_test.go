@@ -0,0 +1,111 @@
+package locerr
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestAnnotationMessage(t *testing.T) {
+	src := NewDummySource("abc\ndef")
+	s := Pos{4, 2, 1, src}
+	e := Pos{6, 2, 3, src}
+	loc := " (at <dummy>:2:1)"
+	snip := "\n\n> def\n"
+
+	cases := []struct {
+		what string
+		err  *Error
+		want string
+	}{
+		{
+			what: "NoteIn method",
+			err:  NewError("text").NoteIn(s, e, "note"),
+			want: "Error: text\n  Note: note" + loc + snip,
+		},
+		{
+			what: "NotefIn method",
+			err:  NewError("text").NotefIn(s, e, "note %d", 42),
+			want: "Error: text\n  Note: note 42" + loc + snip,
+		},
+		{
+			what: "Help method",
+			err:  ErrorAt(s, "text").Help("help"),
+			want: "Error: text" + loc + snip + "\n  Help: help",
+		},
+		{
+			what: "HelpAt method",
+			err:  NewError("text").HelpAt(s, "help"),
+			want: "Error: text\n  Help: help" + loc + snip,
+		},
+		{
+			what: "HelpIn method",
+			err:  NewError("text").HelpIn(s, e, "help"),
+			want: "Error: text\n  Help: help" + loc + snip,
+		},
+		{
+			what: "Annotate method",
+			err:  NewError("text").Annotate(Annotation{Kind: AnnotationHelp, Message: "help"}),
+			want: "Error: text\n  Help: help",
+		},
+		{
+			what: "mixed",
+			err:  NewError("text").Note("note1").HelpAt(s, "help").NoteAt(s, "note2"),
+			want: "Error: text\n  Note: note1\n  Help: help" + loc + snip + "\n  Note: note2" + loc + snip,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.what, func(t *testing.T) {
+			have := tc.err.Error()
+			if have != tc.want {
+				t.Fatalf("Unexpected error message.\nwant:\n'%s'\nhave:\n'%s'", tc.want, have)
+			}
+		})
+	}
+}
+
+func TestAnnotationKeepsPosition(t *testing.T) {
+	defer func(saved bool) { color.NoColor = saved }(color.NoColor)
+	SetColor(true)
+
+	src := NewDummySource("abc\ndef")
+	s := Pos{4, 2, 1, src}
+	e := Pos{6, 2, 3, src}
+
+	err := NoteIn(s, e, ErrorAt(s, "text"), "note")
+	if len(err.Notes) != 1 {
+		t.Fatal("Unexpected notes:", err.Notes)
+	}
+	n := err.Notes[0]
+	if n.Message != "note" {
+		t.Fatalf("Note message should not contain any decoration: '%s'", n.Message)
+	}
+	if n.Kind != AnnotationNote {
+		t.Fatal("Unexpected kind:", n.Kind)
+	}
+	if n.Start != s || n.End != e {
+		t.Fatal("Range of note was not kept:", n.Start, n.End)
+	}
+
+	err = Note(fmt.Errorf("text"), "note")
+	if err.Message != "text" || len(err.Notes) != 1 || err.Notes[0].HasPos() {
+		t.Fatal("Unexpected error converted from error value:", err.Message, err.Notes)
+	}
+}
+
+func TestAnnotationKindString(t *testing.T) {
+	for _, tc := range []struct {
+		kind AnnotationKind
+		want string
+	}{
+		{AnnotationNote, "note"},
+		{AnnotationHelp, "help"},
+		{AnnotationKind(42), "annotation(42)"},
+	} {
+		if have := tc.kind.String(); have != tc.want {
+			t.Errorf("Wanted '%s' but have '%s'", tc.want, have)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package locerr
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	githubMessageEscaper  = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	githubPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+// workingDir is a directory which paths in reports for CI are relative to. CI services run
+// commands at the root of repository.
+var workingDir, _ = os.Getwd()
+
+// ciPath returns the path of the source for CI services. Since they need paths relative to the
+// root of repository, paths of existing files in the working directory are made relative.
+func ciPath(src *Source) string {
+	p := src.Path
+	if src.Exists && workingDir != "" && filepath.IsAbs(p) {
+		if rel, err := filepath.Rel(workingDir, p); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			p = rel
+		}
+	}
+	return filepath.ToSlash(p)
+}
+
+func githubCommand(s Severity) string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo, SeverityHint:
+		return "notice"
+	default:
+		return "error"
+	}
+}
+
+// messageWithNotes returns the message of the error followed by its notes in plain text.
+func messageWithNotes(err *Error) string {
+	var b strings.Builder
+	b.WriteString(err.Message)
+	for _, n := range err.Notes {
+		fmt.Fprintf(&b, "\n%s: %s", n.Kind.Label(), n.Message)
+		if n.HasPos() {
+			fmt.Fprintf(&b, " (at %s)", n.Start.String())
+		}
+	}
+	return b.String()
+}
+
+// lastColumn returns the column of the last character in the range. The range must be in one line.
+func lastColumn(start, end Pos) int {
+	code := end.File.Code
+	if len(code) < end.Offset || start.Offset < 0 {
+		return end.Column - 1 // Source code is not available
+	}
+	_, size := utf8.DecodeLastRune(code[start.Offset:end.Offset])
+	return end.File.Column(end.Offset-size, columnUnit)
+}
+
+// WriteGitHubActions writes the errors as workflow commands of GitHub Actions such as
+// '::error file={path},line={line},col={col}::{message}'. They are shown as annotations on pull
+// requests. Warnings are written as 'warning' commands and informational messages and hints are
+// written as 'notice' commands. Columns are counted in the unit set by SetColumnUnit. endColumn is
+// inclusive and is only written when the range is in one line as GitHub requires. Notes are
+// appended to the message. Paths of files in the working directory are written relative to it so
+// that annotations are attached to the files in the repository.
+func WriteGitHubActions(w io.Writer, errs []*Error) error {
+	for _, err := range errs {
+		props := []string{}
+		if s := err.Start; s.File != nil {
+			col := s.ColumnIn(columnUnit)
+			props = append(props,
+				"file="+githubPropertyEscaper.Replace(ciPath(s.File)),
+				fmt.Sprintf("line=%d", s.Line),
+				fmt.Sprintf("col=%d", col))
+			if e := err.End; e.File != nil && e.Offset > s.Offset {
+				props = append(props, fmt.Sprintf("endLine=%d", e.Line))
+				if e.Line == s.Line {
+					props = append(props, fmt.Sprintf("endColumn=%d", lastColumn(s, e)))
+				}
+			}
+		}
+		if err.Code != "" {
+			props = append(props, "title="+githubPropertyEscaper.Replace(err.Code))
+		}
+
+		cmd := "::" + githubCommand(err.Severity)
+		if len(props) > 0 {
+			cmd += " " + strings.Join(props, ",")
+		}
+		if _, e := fmt.Fprintf(w, "%s::%s\n", cmd, githubMessageEscaper.Replace(messageWithNotes(err))); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+type gitlabPos struct {
+	Line   int `json:"line"`
+	Column int `json:"column,omitempty"`
+}
+
+type gitlabLocation struct {
+	Path      string `json:"path"`
+	Positions struct {
+		Begin gitlabPos `json:"begin"`
+		End   gitlabPos `json:"end"`
+	} `json:"positions"`
+}
+
+type gitlabIssue struct {
+	Type        string         `json:"type"`
+	CheckName   string         `json:"check_name"`
+	Description string         `json:"description"`
+	Severity    string         `json:"severity"`
+	Fingerprint string         `json:"fingerprint"`
+	Location    gitlabLocation `json:"location"`
+}
+
+func gitlabSeverity(s Severity) string {
+	switch s {
+	case SeverityWarning:
+		return "minor"
+	case SeverityInfo, SeverityHint:
+		return "info"
+	default:
+		return "major"
+	}
+}
+
+// fingerprintText returns the code in the range of the error. When the error has no range, the
+// line at the start position is returned. It is used to make fingerprints stable against moving
+// the code to other lines.
+func fingerprintText(err *Error) []byte {
+	if t := err.Range().Text(); t != nil {
+		return t
+	}
+	s := err.Start
+	if s.File == nil {
+		return nil
+	}
+	head := s.File.LineStart(s.Line)
+	if head < 0 {
+		return nil
+	}
+	return bytes.TrimSpace(s.File.Code[head:lineEndOffset(s.File.Code, head)])
+}
+
+// WriteGitLabCodeQuality writes the errors as a report of GitLab Code Quality in JSON. Errors are
+// mapped to 'major' issues, warnings are mapped to 'minor' issues and informational messages and
+// hints are mapped to 'info' issues. Check name is the code of the error, or its severity when it
+// has no code. Fingerprint is a SHA-256 hash of the path, the code, the message and the code
+// snippet of the error. It does not contain line numbers so that it is stable when the code is
+// moved to other lines. When the same fingerprint appears multiple times, the number of
+// occurrences is also hashed to keep fingerprints unique. Errors without position are reported at
+// the first line of a file whose path is empty. Paths are relative to the working directory as
+// WriteGitHubActions.
+func WriteGitLabCodeQuality(w io.Writer, errs []*Error) error {
+	issues := make([]gitlabIssue, 0, len(errs))
+	seen := map[string]int{}
+	for _, err := range errs {
+		i := gitlabIssue{
+			Type:        "issue",
+			CheckName:   err.Code,
+			Description: messageWithNotes(err),
+			Severity:    gitlabSeverity(err.Severity),
+		}
+		if i.CheckName == "" {
+			i.CheckName = err.Severity.String()
+		}
+
+		path := ""
+		i.Location.Positions.Begin = gitlabPos{Line: 1}
+		i.Location.Positions.End = gitlabPos{Line: 1}
+		if s := err.Start; s.File != nil {
+			path = ciPath(s.File)
+			i.Location.Positions.Begin = gitlabPos{s.Line, s.ColumnIn(columnUnit)}
+			i.Location.Positions.End = i.Location.Positions.Begin
+			if e := err.End; e.File != nil && e.Offset > s.Offset {
+				i.Location.Positions.End = gitlabPos{e.Line, e.ColumnIn(columnUnit)}
+			}
+		}
+		i.Location.Path = path
+
+		h := sha256.New()
+		for _, s := range [][]byte{[]byte(path), []byte(err.Code), []byte(err.Message), fingerprintText(err)} {
+			h.Write(s)
+			h.Write([]byte{0})
+		}
+		fp := hex.EncodeToString(h.Sum(nil))
+		if n := seen[fp]; n > 0 {
+			// Same issue appeared again. Make its fingerprint unique with the number of occurrences
+			h.Write([]byte(fmt.Sprint(n)))
+			seen[fp] = n + 1
+			fp = hex.EncodeToString(h.Sum(nil))
+		} else {
+			seen[fp] = 1
+		}
+		i.Fingerprint = fp
+
+		issues = append(issues, i)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(issues)
+}
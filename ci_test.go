@@ -0,0 +1,211 @@
+package locerr
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteGitHubActions(t *testing.T) {
+	defer func(saved ColumnUnit) { columnUnit = saved }(columnUnit)
+
+	src := &Source{Path: "path/to/a,b:c.txt", Code: []byte("let x = 1\nfoo(あ b)\n")}
+	other := NewDummySource("def")
+
+	for _, tc := range []struct {
+		what string
+		err  *Error
+		unit ColumnUnit
+		want string
+	}{
+		{"without position", NewError("text"), ColumnBytes, "::error::text\n"},
+		{
+			"position",
+			WarningAt(src.PosAt(4), "text"),
+			ColumnBytes,
+			"::warning file=path/to/a%2Cb%3Ac.txt,line=1,col=5::text\n",
+		},
+		{
+			"range in one line",
+			ErrorIn(src.PosAt(4), src.PosAt(5), "text").WithCode("E0001"),
+			ColumnBytes,
+			"::error file=path/to/a%2Cb%3Ac.txt,line=1,col=5,endLine=1,endColumn=5,title=E0001::text\n",
+		},
+		{
+			"range across lines",
+			InfoIn(src.PosAt(4), src.PosAt(13), "text"),
+			ColumnBytes,
+			"::notice file=path/to/a%2Cb%3Ac.txt,line=1,col=5,endLine=2::text\n",
+		},
+		{
+			"non-ascii in bytes",
+			HintIn(src.PosAt(14), src.PosAt(17), "text"),
+			ColumnBytes,
+			"::notice file=path/to/a%2Cb%3Ac.txt,line=2,col=5,endLine=2,endColumn=5::text\n",
+		},
+		{
+			"non-ascii in runes",
+			HintIn(src.PosAt(14), src.PosAt(19), "text"),
+			ColumnRunes,
+			"::notice file=path/to/a%2Cb%3Ac.txt,line=2,col=5,endLine=2,endColumn=7::text\n",
+		},
+		{
+			"escape message",
+			NewError("100% done\r\nnext line").Note("note").NoteAt(other.PosAt(1), "note with position"),
+			ColumnBytes,
+			"::error::100%25 done%0D%0Anext line%0ANote: note%0ANote: note with position (at <dummy>:1:2)\n",
+		},
+		{"escape code", NewError("text").WithCode("a:b,c"), ColumnBytes, "::error title=a%3Ab%2Cc::text\n"},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			SetColumnUnit(tc.unit)
+			var buf bytes.Buffer
+			if err := WriteGitHubActions(&buf, []*Error{tc.err}); err != nil {
+				t.Fatal(err)
+			}
+			if have := buf.String(); have != tc.want {
+				t.Fatalf("Unexpected output.\nwant:\n'%s'\nhave:\n'%s'", tc.want, have)
+			}
+		})
+	}
+}
+
+func TestCIPathRelativeToWorkingDir(t *testing.T) {
+	defer func(saved string) { workingDir = saved }(workingDir)
+	dir, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	workingDir = filepath.Dir(dir)
+
+	src, err := NewSourceFromFile(filepath.Join("testdata", "sarif-schema-2.1.0.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	outside := &Source{Path: filepath.Join(filepath.Dir(workingDir), "outside.txt"), Code: []byte("abc"), Exists: true}
+	virtual := &Source{Path: filepath.Join(dir, "virtual.txt"), Code: []byte("abc")}
+
+	for _, tc := range []struct {
+		what string
+		src  *Source
+		want string
+	}{
+		{"file in working directory", src, "testdata/sarif-schema-2.1.0.json"},
+		{"file outside working directory", outside, filepath.ToSlash(outside.Path)},
+		{"file which does not exist", virtual, filepath.ToSlash(virtual.Path)},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			errs := []*Error{ErrorAt(tc.src.PosAt(0), "text")}
+
+			var buf bytes.Buffer
+			if err := WriteGitHubActions(&buf, errs); err != nil {
+				t.Fatal(err)
+			}
+			if want := "file=" + githubPropertyEscaper.Replace(tc.want) + ","; !strings.Contains(buf.String(), want) {
+				t.Fatalf("GitHub Actions command should contain %q but have %q", want, buf.String())
+			}
+
+			buf.Reset()
+			if err := WriteGitLabCodeQuality(&buf, errs); err != nil {
+				t.Fatal(err)
+			}
+			var issues []gitlabIssue
+			if err := json.Unmarshal(buf.Bytes(), &issues); err != nil {
+				t.Fatal(err)
+			}
+			if have := issues[0].Location.Path; have != tc.want {
+				t.Fatalf("GitLab Code Quality path should be %q but have %q", tc.want, have)
+			}
+		})
+	}
+}
+
+func TestWriteGitLabCodeQuality(t *testing.T) {
+	src := &Source{Path: "path/to/test.txt", Code: []byte("let x = 1\nfoo(a b)\n")}
+	errs := []*Error{
+		ErrorIn(src.PosAt(4), src.PosAt(5), "text").WithCode("E0001"),
+		WarningAt(src.PosAt(14), "text"),
+		InfoIn(src.PosAt(4), src.PosAt(13), "text").Note("note"),
+		NewError("without position"),
+		ErrorIn(src.PosAt(4), src.PosAt(5), "text").WithCode("E0001"),
+	}
+
+	var buf bytes.Buffer
+	if err := WriteGitLabCodeQuality(&buf, errs); err != nil {
+		t.Fatal(err)
+	}
+	var issues []gitlabIssue
+	if err := json.Unmarshal(buf.Bytes(), &issues); err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 5 {
+		t.Fatal("Unexpected number of issues:", len(issues))
+	}
+
+	for i, want := range []struct {
+		check    string
+		desc     string
+		severity string
+		path     string
+		begin    gitlabPos
+		end      gitlabPos
+	}{
+		{"E0001", "text", "major", "path/to/test.txt", gitlabPos{1, 5}, gitlabPos{1, 6}},
+		{"warning", "text", "minor", "path/to/test.txt", gitlabPos{2, 5}, gitlabPos{2, 5}},
+		{"info", "text\nNote: note", "info", "path/to/test.txt", gitlabPos{1, 5}, gitlabPos{2, 4}},
+		{"error", "without position", "major", "", gitlabPos{Line: 1}, gitlabPos{Line: 1}},
+	} {
+		have := issues[i]
+		if have.Type != "issue" || have.CheckName != want.check || have.Description != want.desc || have.Severity != want.severity {
+			t.Errorf("Unexpected issue at %d: %+v", i, have)
+		}
+		if l := have.Location; l.Path != want.path || l.Positions.Begin != want.begin || l.Positions.End != want.end {
+			t.Errorf("Unexpected location at %d: %+v", i, have.Location)
+		}
+	}
+
+	seen := map[string]struct{}{}
+	for i, issue := range issues {
+		if len(issue.Fingerprint) != 64 {
+			t.Errorf("Fingerprint at %d is not SHA-256: %q", i, issue.Fingerprint)
+		}
+		if _, ok := seen[issue.Fingerprint]; ok {
+			t.Errorf("Fingerprint at %d is duplicate: %q", i, issue.Fingerprint)
+		}
+		seen[issue.Fingerprint] = struct{}{}
+	}
+
+	// Fingerprints should not change when the code is moved to other lines
+	moved := &Source{Path: "path/to/test.txt", Code: []byte("\n\nlet x = 1\nfoo(a b)\n")}
+	buf.Reset()
+	if err := WriteGitLabCodeQuality(&buf, []*Error{
+		ErrorIn(moved.PosAt(6), moved.PosAt(7), "text").WithCode("E0001"),
+		WarningAt(moved.PosAt(16), "text"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var movedIssues []gitlabIssue
+	if err := json.Unmarshal(buf.Bytes(), &movedIssues); err != nil {
+		t.Fatal(err)
+	}
+	for i, issue := range movedIssues {
+		if issue.Fingerprint != issues[i].Fingerprint {
+			t.Errorf("Fingerprint at %d was changed after moving code: %q vs %q", i, issue.Fingerprint, issues[i].Fingerprint)
+		}
+		if issue.Location.Positions.Begin.Line != issues[i].Location.Positions.Begin.Line+2 {
+			t.Errorf("Line at %d should be moved: %+v", i, issue.Location)
+		}
+	}
+}
+
+func TestWriteGitLabCodeQualityEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteGitLabCodeQuality(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if have := strings.TrimSpace(buf.String()); have != "[]" {
+		t.Fatal("Empty report should be an empty array:", have)
+	}
+}
@@ -0,0 +1,197 @@
+package locerr
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ColumnUnit is a unit to count columns in a line.
+type ColumnUnit int
+
+const (
+	// ColumnBytes counts columns in bytes. This is the default unit.
+	ColumnBytes ColumnUnit = iota
+	// ColumnRunes counts columns in Unicode code points.
+	ColumnRunes
+	// ColumnUTF16 counts columns in UTF-16 code units. This is the unit used by LSP and JavaScript.
+	ColumnUTF16
+	// ColumnGraphemes counts columns in grapheme clusters, which are characters perceived by users.
+	// Boundaries of grapheme clusters are approximated following the rules of extended grapheme
+	// clusters in Unicode Standard Annex #29.
+	ColumnGraphemes
+)
+
+// String returns the name of the unit such as "bytes" or "utf-16".
+func (u ColumnUnit) String() string {
+	switch u {
+	case ColumnBytes:
+		return "bytes"
+	case ColumnRunes:
+		return "runes"
+	case ColumnUTF16:
+		return "utf-16"
+	case ColumnGraphemes:
+		return "graphemes"
+	default:
+		return fmt.Sprintf("unit(%d)", int(u))
+	}
+}
+
+var columnUnit = ColumnBytes
+
+// SetColumnUnit sets the unit of column reported by Pos.String(). When the unit is not
+// ColumnBytes, the column is calculated from the offset of the position. Pos.Column is assumed to
+// be in bytes. Like SetColor, it affects all positions stringized after the call.
+func SetColumnUnit(u ColumnUnit) {
+	columnUnit = u
+}
+
+// Count counts the length of the text in the unit.
+func (u ColumnUnit) Count(text []byte) int {
+	switch u {
+	case ColumnRunes:
+		return utf8.RuneCount(text)
+	case ColumnUTF16:
+		n := 0
+		for len(text) > 0 {
+			r, s := utf8.DecodeRune(text)
+			if r >= 0x10000 {
+				n += 2 // Surrogate pair
+			} else {
+				n++
+			}
+			text = text[s:]
+		}
+		return n
+	case ColumnGraphemes:
+		return countGraphemes(text)
+	default:
+		return len(text)
+	}
+}
+
+// Column returns the column at the offset counted in the unit. Column starts from 1. Offset is
+// clamped into the range of the source.
+func (src *Source) Column(offset int, unit ColumnUnit) int {
+	p := src.PosAt(offset)
+	head := p.Offset - p.Column + 1
+	return unit.Count(src.Code[head:p.Offset]) + 1
+}
+
+type graphemeProp int
+
+const (
+	gpOther graphemeProp = iota
+	gpCR
+	gpLF
+	gpControl
+	gpExtend
+	gpZWJ
+	gpRegionalIndicator
+	gpSpacingMark
+	gpL
+	gpV
+	gpT
+	gpLV
+	gpLVT
+	gpPictographic
+)
+
+func graphemePropOf(r rune) graphemeProp {
+	switch {
+	case r == '\r':
+		return gpCR
+	case r == '\n':
+		return gpLF
+	case r == 0x200d:
+		return gpZWJ
+	case 0x1f1e6 <= r && r <= 0x1f1ff:
+		return gpRegionalIndicator
+	case 0x1f3fb <= r && r <= 0x1f3ff, 0xe0020 <= r && r <= 0xe007f, r == 0x200c:
+		// Emoji modifiers, tags and ZWNJ
+		return gpExtend
+	case unicode.In(r, unicode.Mn, unicode.Me):
+		return gpExtend
+	case unicode.Is(unicode.Mc, r):
+		return gpSpacingMark
+	case unicode.In(r, unicode.Cc, unicode.Zl, unicode.Zp), unicode.Is(unicode.Cf, r) && r != 0x200d:
+		return gpControl
+	case 0x1100 <= r && r <= 0x115f, 0xa960 <= r && r <= 0xa97c:
+		return gpL
+	case 0x1160 <= r && r <= 0x11a7, 0xd7b0 <= r && r <= 0xd7c6:
+		return gpV
+	case 0x11a8 <= r && r <= 0x11ff, 0xd7cb <= r && r <= 0xd7fb:
+		return gpT
+	case 0xac00 <= r && r <= 0xd7a3:
+		if (r-0xac00)%28 == 0 {
+			return gpLV
+		}
+		return gpLVT
+	case 0x1f000 <= r && r <= 0x1faff, 0x2600 <= r && r <= 0x27bf, r == 0x00a9, r == 0x00ae, r == 0x203c, r == 0x2049:
+		// Approximation of Extended_Pictographic
+		return gpPictographic
+	default:
+		return gpOther
+	}
+}
+
+// isGraphemeBoundary returns whether there is a boundary of grapheme clusters between prev and next.
+// riCount is the number of regional indicators continuing just before next. pict is true when
+// the sequence before next is an pictographic character followed by Extend* ZWJ.
+func isGraphemeBoundary(prev, next graphemeProp, riCount int, pict bool) bool {
+	switch {
+	case prev == gpCR && next == gpLF: // GB3
+		return false
+	case prev == gpCR || prev == gpLF || prev == gpControl: // GB4
+		return true
+	case next == gpCR || next == gpLF || next == gpControl: // GB5
+		return true
+	case prev == gpL && (next == gpL || next == gpV || next == gpLV || next == gpLVT): // GB6
+		return false
+	case (prev == gpLV || prev == gpV) && (next == gpV || next == gpT): // GB7
+		return false
+	case (prev == gpLVT || prev == gpT) && next == gpT: // GB8
+		return false
+	case next == gpExtend || next == gpZWJ || next == gpSpacingMark: // GB9, GB9a
+		return false
+	case prev == gpZWJ && next == gpPictographic && pict: // GB11
+		return false
+	case prev == gpRegionalIndicator && next == gpRegionalIndicator: // GB12, GB13
+		return riCount%2 == 0
+	default: // GB999
+		return true
+	}
+}
+
+func countGraphemes(text []byte) int {
+	n := 0
+	prev := gpOther
+	riCount := 0
+	pict, pictExtend := false, false
+	for i, r := range string(text) {
+		p := graphemePropOf(r)
+		if i == 0 || isGraphemeBoundary(prev, p, riCount, pict) {
+			n++
+		}
+
+		if p == gpRegionalIndicator {
+			riCount++
+		} else {
+			riCount = 0
+		}
+		// Track Extended_Pictographic Extend* ZWJ sequence for GB11
+		switch p {
+		case gpPictographic:
+			pictExtend, pict = true, false
+		case gpExtend:
+			pict = false
+		case gpZWJ:
+			pict, pictExtend = pictExtend, false
+		default:
+			pict, pictExtend = false, false
+		}
+		prev = p
+	}
+	return n
+}
@@ -0,0 +1,97 @@
+package locerr
+
+import (
+	"testing"
+)
+
+func TestColumnUnitCount(t *testing.T) {
+	for _, tc := range []struct {
+		text      string
+		bytes     int
+		runes     int
+		utf16     int
+		graphemes int
+	}{
+		{"", 0, 0, 0, 0},
+		{"abc", 3, 3, 3, 3},
+		{"あいう", 9, 3, 3, 3},
+		{"😀", 4, 1, 2, 1},
+		{"e\u0301", 3, 2, 2, 1},                                     // e + combining acute accent
+		{"\r\n", 2, 2, 2, 1},                                        // CRLF
+		{"🇯🇵🇺🇸", 16, 4, 8, 2},                                       // Regional indicators
+		{"\U0001F44D\U0001F3FD", 8, 2, 4, 1},                        // Emoji modifier
+		{"\U0001F468\u200d\U0001F469\u200d\U0001F467", 18, 5, 8, 1}, // ZWJ sequence
+		{"a\u200db", 5, 3, 3, 2},                                    // ZWJ not followed by pictograph
+		{"\u1100\u1161\u11a8", 9, 3, 3, 1},                          // Hangul jamo L V T
+		{"\uac00\u11a8", 6, 2, 2, 1},                                // Hangul syllable LV + T
+		{"\u0915\u093f", 6, 2, 2, 1},                                // Devanagari with spacing mark
+		{"\xff", 1, 1, 1, 1},                                        // Invalid UTF-8
+	} {
+		t.Run(tc.text, func(t *testing.T) {
+			b := []byte(tc.text)
+			for _, c := range []struct {
+				unit ColumnUnit
+				want int
+			}{
+				{ColumnBytes, tc.bytes},
+				{ColumnRunes, tc.runes},
+				{ColumnUTF16, tc.utf16},
+				{ColumnGraphemes, tc.graphemes},
+			} {
+				if have := c.unit.Count(b); have != c.want {
+					t.Errorf("Length of %q in %s should be %d but %d", tc.text, c.unit, c.want, have)
+				}
+			}
+		})
+	}
+}
+
+func TestSourceColumn(t *testing.T) {
+	src := NewDummySource("abc\nあ😀e\u0301x")
+	offset := len("abc\nあ😀e\u0301")
+	for _, tc := range []struct {
+		unit ColumnUnit
+		want int
+	}{
+		{ColumnBytes, 11},
+		{ColumnRunes, 5},
+		{ColumnUTF16, 6},
+		{ColumnGraphemes, 4},
+	} {
+		if have := src.Column(offset, tc.unit); have != tc.want {
+			t.Errorf("Column in %s should be %d but %d", tc.unit, tc.want, have)
+		}
+	}
+	if have := src.Column(2, ColumnRunes); have != 3 {
+		t.Errorf("Column in first line should be 3 but %d", have)
+	}
+}
+
+func TestSetColumnUnit(t *testing.T) {
+	defer func(saved ColumnUnit) { columnUnit = saved }(columnUnit)
+
+	src := NewDummySource("abc\nあ😀x")
+	p := src.PosAt(len("abc\nあ😀"))
+
+	for _, tc := range []struct {
+		unit ColumnUnit
+		want string
+	}{
+		{ColumnBytes, "<dummy>:2:8"},
+		{ColumnRunes, "<dummy>:2:3"},
+		{ColumnUTF16, "<dummy>:2:4"},
+		{ColumnGraphemes, "<dummy>:2:3"},
+	} {
+		SetColumnUnit(tc.unit)
+		if have := p.String(); have != tc.want {
+			t.Errorf("Position in %s should be %s but %s", tc.unit, tc.want, have)
+		}
+	}
+
+	// Offset is unknown
+	SetColumnUnit(ColumnRunes)
+	q := Pos{0, 2, 8, src}
+	if have := q.String(); have != "<dummy>:2:8" {
+		t.Errorf("Column should be used as-is when offset is unknown but %s", have)
+	}
+}
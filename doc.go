@@ -34,10 +34,17 @@ Let's say to find an error at some range in the source.
     end := locerr.Pos{
     	Offset: 52,
     	Line:   6,
-    	Column: 12,
+    	Column: 13,
     	File:   src,
     }
 
+Source.PosAt() also makes a position from an offset. Hand-built positions can be checked with
+Pos.Validate(), which reports the line and column which do not agree with the offset.
+
+    if err := end.Validate(); err != nil {
+    	panic(err)
+    }
+
 ErrorIn or other factory functions make a new error instance with the range. Error instance implements
 error interface so it can be handled like other error types.
 
@@ -47,7 +54,7 @@ Assume that you find additional information (location of variable and its type).
 notes to the error. Notes can be added by wrapping errors like pkg/errors library.
 
     prev := locerr.Pos{
-    	Offset: 26,
+    	Offset: 28,
     	Line:   4,
     	Column: 1,
     	File:   src,
@@ -63,12 +70,20 @@ non-Windows OS.
 
 It should output following:
 
-    Error: Found duplicate symbol 'foo' (at <dummy>:6:1)
+    Error: Found duplicate symbol 'foo' (at <dummy>:6:2)
+
+    >       foo := true
+
       Note: Defined here at first (at <dummy>:4:1)
+
+    >       foo := 42
+
       Note: Previously defined as int (at <dummy>:4:1)
 
-    >       foo := true
+    >       foo := 42
 
+Each note which has its own position shows its code snippet under the note. The position may be in
+another source file than the error's one.
 
 To support Windows, please use PrintToFile() method. It directly writes the error message into given file.
 This supports Windows and is useful to output from stdout or stderr.
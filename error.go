@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"strings"
 
 	"github.com/fatih/color"
 	"github.com/mattn/go-colorable"
@@ -22,148 +21,65 @@ var (
 	green    = color.New(color.FgGreen)
 	gray     = color.New(color.FgHiBlack)
 	emphasis = color.New(color.FgHiGreen, color.Bold, color.Underline)
+	marker   = color.New(color.FgHiGreen, color.Bold)
 )
 
 // Error represents a compilation error with positional information and stacked messages.
 type Error struct {
-	Start    Pos
-	End      Pos
-	Messages []string
-}
-
-func writeSnipLine(w io.Writer, line string) {
-	indent, len := 0, len(line)
-	for indent < len {
-		if line[indent] != ' ' && line[indent] != '\t' {
-			break
-		}
-		indent++
-	}
-	if indent != 0 {
-		// Write indent without emphasis
-		fmt.Fprint(w, line[:indent])
-	}
-	if indent != len {
-		// Write code snip with emphasis
-		emphasis.Fprint(w, line[indent:])
-	}
-}
-
-func (err *Error) writeSnip(w io.Writer) {
-	fmt.Fprint(w, "\n\n> ")
-
-	code := err.Start.File.Code
-	start := err.Start.Offset
-	for start-1 >= 0 {
-		if code[start-1] == '\n' {
-			break
-		}
-		start--
-	}
-	if start < err.Start.Offset {
-		// Write code before snip in first line
-		w.Write(code[start:err.Start.Offset])
-	}
-
-	lines := strings.Split(string(code[err.Start.Offset:err.End.Offset]), "\n")
-
-	// First line does not have "> " prefix
-	writeSnipLine(w, lines[0])
-
-	for _, line := range lines[1:] {
-		fmt.Fprint(w, "\n> ")
-		writeSnipLine(w, line)
-	}
-
-	end := err.End.Offset
-	len := len(code)
-	for end < len {
-		if code[end] == '\n' {
-			break
-		}
-		end++
-	}
-	if err.End.Offset < end {
-		// Write code after snip in last line
-		w.Write(code[err.End.Offset:end])
-	}
-
-	fmt.Fprint(w, "\n")
-
-	// TODO:
-	// If the code snippet for the token is too long, skip lines with '...' except for starting N lines
-	// and ending N lines
-}
-
-func lineStartOffset(code []byte, lnum int) int {
-	l := 1
-	for i, r := range code {
-		if l == lnum {
-			return i
-		}
-		if r == '\n' {
-			l++
-		}
-	}
-	return -1
-}
-
-// Show line based on err.Start.Line. We don't use offset for this because some environment offset
-// cannot be obtained (e.g. getting location from runtime.Caller).
-func (err *Error) writeOnelineSnip(w io.Writer) {
-	code := err.Start.File.Code
-	len := len(code)
-	if len == 0 {
-		return
-	}
-
-	start := lineStartOffset(code, err.Start.Line)
-	if start == -1 {
-		return
-	}
-
-	end := start
-	for end < len {
-		if code[end] == '\n' {
-			break
-		}
-		end++
-	}
-
-	if start == end {
-		// Snippet is empty. Skipped.
-		return
-	}
-
-	fmt.Fprint(w, "\n\n> ")
-	w.Write(code[start:end])
-	w.Write([]byte{'\n'})
+	Start Pos
+	End   Pos
+	// Message is the main message of the error.
+	Message string
+	// Notes are additional messages stacked on the error. They are shown in stacked order.
+	Notes []Annotation
+	// Severity of the error. Label and its color in error message are decided by this value.
+	Severity Severity
+	// Code is an optional identifier of the error such as "E0042". It is shown in the label like
+	// "Error[E0042]:" so that users can search for it or look up its explanation in a Registry.
+	Code string
+	// Suggestions are edits to fix the error. They are shown after notes.
+	Suggestions []Suggestion
+
+	// Original error when this error was made from another error value
+	cause error
 }
 
 // WriteMessage writes error message to the given writer
 func (err *Error) WriteMessage(w io.Writer) {
-	// Error: {msg} (at {pos})
-	//   {note1}
-	//   {note2}
+	// {Label}[{code}]: {msg} (at {pos})
+	//
+	// > {snippet}
+	//
+	//   Note: {note1} (at {pos})
+	//
+	// > {snippet of note1}
+	//
+	//   Note: {note2}
 	//   ...
-	red.Fprint(w, "Error: ")
-	bold.Fprint(w, err.Messages[0])
+	//
+	//   Help: {suggestion}
+	//
+	// > {patched snippet}
+	label := err.Severity.Label()
+	if err.Code != "" {
+		label += "[" + err.Code + "]"
+	}
+	err.Severity.color().Fprint(w, label+": ")
+	bold.Fprint(w, err.Message)
 	if err.Start.File != nil {
 		gray.Fprintf(w, " (at %s)", err.Start.String())
 	}
-	for _, msg := range err.Messages[1:] {
-		green.Fprint(w, "\n  Note: ")
-		fmt.Fprint(w, msg)
-	}
+	writeSnippet(w, err.Start, err.End, &options)
 
-	if err.Start.File == nil {
-		return
+	for i := range err.Notes {
+		n := &err.Notes[i]
+		n.writeMessage(w)
+		writeSnippet(w, n.Start, n.End, &options)
 	}
-	if err.End.File == nil || err.Start.Offset == err.End.Offset {
-		err.writeOnelineSnip(w)
-		return
+
+	for i := range err.Suggestions {
+		err.Suggestions[i].writeMessage(w, &options)
 	}
-	err.writeSnip(w)
 }
 
 // Error builds error message for the error.
@@ -179,23 +95,35 @@ func (err *Error) PrintToFile(f *os.File) {
 	err.WriteMessage(colorable.NewColorable(f))
 }
 
+// Annotate stacks the given annotation upon current error.
+func (err *Error) Annotate(a Annotation) *Error {
+	err.Notes = append(err.Notes, a)
+	return err
+}
+
 // Note stacks the additional message upon current error.
 func (err *Error) Note(msg string) *Error {
-	err.Messages = append(err.Messages, msg)
-	return err
+	return err.Annotate(Annotation{Kind: AnnotationNote, Message: msg})
 }
 
 // Notef stacks the additional formatted message upon current error.
 func (err *Error) Notef(format string, args ...interface{}) *Error {
-	err.Messages = append(err.Messages, fmt.Sprintf(format, args...))
-	return err
+	return err.Note(fmt.Sprintf(format, args...))
+}
+
+// NoteIn stacks the additional message upon current error with range.
+func (err *Error) NoteIn(start, end Pos, msg string) *Error {
+	return err.Annotate(Annotation{Kind: AnnotationNote, Message: msg, Start: start, End: end})
+}
+
+// NotefIn stacks the additional formatted message upon current error with range.
+func (err *Error) NotefIn(start, end Pos, format string, args ...interface{}) *Error {
+	return err.NoteIn(start, end, fmt.Sprintf(format, args...))
 }
 
 // NoteAt stacks the additional message upon current error with position.
 func (err *Error) NoteAt(pos Pos, msg string) *Error {
-	at := color.HiBlackString("(at %s)", pos.String())
-	err.Messages = append(err.Messages, fmt.Sprintf("%s %s", msg, at))
-	return err
+	return err.NoteIn(pos, Pos{}, msg)
 }
 
 // NotefAt stacks the additional formatted message upon current error with poisition.
@@ -203,6 +131,27 @@ func (err *Error) NotefAt(pos Pos, format string, args ...interface{}) *Error {
 	return err.NoteAt(pos, fmt.Sprintf(format, args...))
 }
 
+// Help stacks the additional advice to fix the error upon current error.
+func (err *Error) Help(msg string) *Error {
+	return err.Annotate(Annotation{Kind: AnnotationHelp, Message: msg})
+}
+
+// HelpIn stacks the additional advice to fix the error upon current error with range.
+func (err *Error) HelpIn(start, end Pos, msg string) *Error {
+	return err.Annotate(Annotation{Kind: AnnotationHelp, Message: msg, Start: start, End: end})
+}
+
+// HelpAt stacks the additional advice to fix the error upon current error with position.
+func (err *Error) HelpAt(pos Pos, msg string) *Error {
+	return err.HelpIn(pos, Pos{}, msg)
+}
+
+// WithCode sets the error code such as "E0042" to the error.
+func (err *Error) WithCode(code string) *Error {
+	err.Code = code
+	return err
+}
+
 // In sets start and end positions of the error.
 func (err *Error) In(start, end Pos) *Error {
 	err.Start = start
@@ -219,12 +168,12 @@ func (err *Error) At(pos Pos) *Error {
 
 // NewError makes locerr.Error instance without source location information.
 func NewError(msg string) *Error {
-	return &Error{Pos{}, Pos{}, []string{msg}}
+	return &Error{Message: msg}
 }
 
 // ErrorIn makes a new compilation error with the range.
 func ErrorIn(start, end Pos, msg string) *Error {
-	return &Error{start, end, []string{msg}}
+	return &Error{Start: start, End: end, Message: msg}
 }
 
 // ErrorAt makes a new compilation error with the position.
@@ -247,14 +196,27 @@ func ErrorfAt(pos Pos, format string, args ...interface{}) *Error {
 	return ErrorIn(pos, Pos{}, fmt.Sprintf(format, args...))
 }
 
+// Unwrap returns the original error when the error was made from another error value by WithPos,
+// WithRange, Note or other functions. Otherwise it returns nil. This makes errors.Is and errors.As
+// work through locerr.Error.
+func (err *Error) Unwrap() error {
+	return err.cause
+}
+
+func wrap(start, end Pos, err error) *Error {
+	e := ErrorIn(start, end, err.Error())
+	e.cause = err
+	return e
+}
+
 // WithRange adds range information to the passed error.
 func WithRange(start, end Pos, err error) *Error {
-	return ErrorIn(start, end, err.Error())
+	return wrap(start, end, err)
 }
 
 // WithPos adds positional information to the passed error.
 func WithPos(pos Pos, err error) *Error {
-	return ErrorAt(pos, err.Error())
+	return wrap(pos, Pos{}, err)
 }
 
 // Note adds note to the given error. If given error is not locerr.Error, it's converted into locerr.Error.
@@ -262,15 +224,15 @@ func Note(err error, msg string) *Error {
 	if err, ok := err.(*Error); ok {
 		return err.Note(msg)
 	}
-	return &Error{Pos{}, Pos{}, []string{err.Error(), msg}}
+	return wrap(Pos{}, Pos{}, err).Note(msg)
 }
 
 // NoteIn adds range information and stack additional message to the original error. If given error is not locerr.Error, it's converted into locerr.Error.
 func NoteIn(start, end Pos, err error, msg string) *Error {
 	if err, ok := err.(*Error); ok {
-		return err.NoteAt(start, msg)
+		return err.NoteIn(start, end, msg)
 	}
-	return &Error{start, end, []string{err.Error(), msg}}
+	return wrap(start, end, err).Note(msg)
 }
 
 // NoteAt adds positional information and stack additional message to the original error. If given error is not locerr.Error, it's converted into locerr.Error.
@@ -1,34 +1,15 @@
 package locerr
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"testing"
 
 	"github.com/fatih/color"
 )
 
-func testCalcPos(src *Source, offset int) Pos {
-	code := src.Code
-	o, l, c, end := 0, 1, 1, len(code)
-	for o != end {
-		if o == offset {
-			return Pos{o, l, c, src}
-		}
-		if code[o] == '\n' {
-			l++
-			c = 1
-		} else {
-			c++
-		}
-		o++
-	}
-	if o != offset {
-		panic("Offsetis illegal")
-	}
-	return Pos{o, l, c, src}
-}
-
 func TestFunctionsAndMethods(t *testing.T) {
 	src := NewDummySource(
 		`int main() {
@@ -112,77 +93,77 @@ func TestFunctionsAndMethods(t *testing.T) {
 		{
 			what: "Note to locerr.Error",
 			err:  Note(ErrorIn(s, e, "This is error text"), "This is note"),
-			want: "Error: This is error text" + loc + "\n  Note: This is note" + snip,
+			want: "Error: This is error text" + loc + snip + "\n  Note: This is note",
 		},
 		{
 			what: "Notef to locerr.Error",
 			err:  Notef(ErrorIn(s, e, "This is error text"), "This is note: %d", 42),
-			want: "Error: This is error text" + loc + "\n  Note: This is note: 42" + snip,
+			want: "Error: This is error text" + loc + snip + "\n  Note: This is note: 42",
 		},
 		{
 			what: "NoteIn to error",
 			err:  NoteIn(s, e, fmt.Errorf("This is error text"), "This is note"),
-			want: "Error: This is error text" + loc + "\n  Note: This is note" + snip,
+			want: "Error: This is error text" + loc + snip + "\n  Note: This is note",
 		},
 		{
 			what: "NotefIn to error",
 			err:  NotefIn(s, e, fmt.Errorf("This is error text"), "This is note: %d", 42),
-			want: "Error: This is error text" + loc + "\n  Note: This is note: 42" + snip,
+			want: "Error: This is error text" + loc + snip + "\n  Note: This is note: 42",
 		},
 		{
 			what: "NoteIn to locerr.Error",
 			err:  NoteIn(s, e, ErrorIn(s, e, "This is error text"), "This is note"),
-			want: "Error: This is error text" + loc + "\n  Note: This is note" + loc + snip,
+			want: "Error: This is error text" + loc + snip + "\n  Note: This is note" + loc + snip,
 		},
 		{
 			what: "NotefIn to locerr.Error",
 			err:  NotefIn(s, e, ErrorIn(s, e, "This is error text"), "This is note: %d", 42),
-			want: "Error: This is error text" + loc + "\n  Note: This is note: 42" + loc + snip,
+			want: "Error: This is error text" + loc + snip + "\n  Note: This is note: 42" + loc + snip,
 		},
 		{
 			what: "NoteAt to error",
 			err:  NoteAt(s, fmt.Errorf("This is error text"), "This is note"),
-			want: "Error: This is error text" + loc + "\n  Note: This is note" + oneline,
+			want: "Error: This is error text" + loc + oneline + "\n  Note: This is note",
 		},
 		{
 			what: "NotefAt to error",
 			err:  NotefAt(s, fmt.Errorf("This is error text"), "This is note: %d", 42),
-			want: "Error: This is error text" + loc + "\n  Note: This is note: 42" + oneline,
+			want: "Error: This is error text" + loc + oneline + "\n  Note: This is note: 42",
 		},
 		{
 			what: "NoteAt to locerr.Error",
 			err:  NoteAt(s, ErrorIn(s, e, "This is error text"), "This is note"),
-			want: "Error: This is error text" + loc + "\n  Note: This is note" + loc + snip,
+			want: "Error: This is error text" + loc + snip + "\n  Note: This is note" + loc + oneline,
 		},
 		{
 			what: "NotefAt to locerr.Error",
 			err:  NotefAt(s, ErrorIn(s, e, "This is error text"), "This is note: %d", 42),
-			want: "Error: This is error text" + loc + "\n  Note: This is note: 42" + loc + snip,
+			want: "Error: This is error text" + loc + snip + "\n  Note: This is note: 42" + loc + oneline,
 		},
 		{
 			what: "Note method",
 			err:  ErrorIn(s, e, "This is error text").Note("This is note"),
-			want: "Error: This is error text" + loc + "\n  Note: This is note" + snip,
+			want: "Error: This is error text" + loc + snip + "\n  Note: This is note",
 		},
 		{
 			what: "Notef method",
 			err:  ErrorIn(s, e, "This is error text").Notef("This is note: %d", 42),
-			want: "Error: This is error text" + loc + "\n  Note: This is note: 42" + snip,
+			want: "Error: This is error text" + loc + snip + "\n  Note: This is note: 42",
 		},
 		{
 			what: "NoteAt method",
 			err:  ErrorIn(s, e, "This is error text").NoteAt(s, "This is note"),
-			want: "Error: This is error text" + loc + "\n  Note: This is note" + loc + snip,
+			want: "Error: This is error text" + loc + snip + "\n  Note: This is note" + loc + oneline,
 		},
 		{
 			what: "NotefAt method",
 			err:  ErrorIn(s, e, "This is error text").NotefAt(s, "This is note: %d", 42),
-			want: "Error: This is error text" + loc + "\n  Note: This is note: 42" + loc + snip,
+			want: "Error: This is error text" + loc + snip + "\n  Note: This is note: 42" + loc + oneline,
 		},
 		{
 			what: "nested notes",
 			err:  Note(ErrorIn(s, e, "This is error text"), "This is note").NoteAt(s, "This is note second"),
-			want: "Error: This is error text" + loc + "\n  Note: This is note\n  Note: This is note second" + loc + snip,
+			want: "Error: This is error text" + loc + snip + "\n  Note: This is note\n  Note: This is note second" + loc + oneline,
 		},
 		{
 			what: "set range later",
@@ -402,7 +383,7 @@ func TestCodeSnippet(t *testing.T) {
 	for _, tc := range cases {
 		t.Run(tc.what, func(t *testing.T) {
 			src := NewDummySource(tc.code)
-			err := ErrorIn(testCalcPos(src, tc.from), testCalcPos(src, tc.to), "text")
+			err := ErrorIn(src.PosAt(tc.from), src.PosAt(tc.to), "text")
 			have := strings.SplitN(err.Error(), "\n", 3)[2]
 			want := strings.Join(tc.want, "\n") + "\n"
 			if have != want {
@@ -506,7 +487,7 @@ func TestSnipIsEmpty(t *testing.T) {
 }
 
 func TestSetColor(t *testing.T) {
-	defer func() { SetColor(true) }()
+	defer func(saved bool) { color.NoColor = saved }(color.NoColor)
 	SetColor(false)
 	if !color.NoColor {
 		t.Fatal("Color should be disabled")
@@ -520,3 +501,77 @@ func TestSetColor(t *testing.T) {
 		t.Fatal("Color should be disabled (2)")
 	}
 }
+
+func TestNoteSnippetInOtherFile(t *testing.T) {
+	main := &Source{Path: "main.dachs", Code: []byte("func foo() {}\nfunc foo(x) {}")}
+	other := &Source{Path: "other.dachs", Code: []byte("// other\nfunc foo() {}")}
+
+	err := ErrorIn(Pos{19, 2, 6, main}, Pos{22, 2, 9, main}, "Redefinition of 'foo'")
+	err = err.NoteIn(Pos{14, 2, 6, other}, Pos{17, 2, 9, other}, "Previous definition in other.dachs")
+	err = err.NoteAt(Pos{5, 1, 6, main}, "Also defined here")
+
+	want := `Error: Redefinition of 'foo' (at main.dachs:2:6)
+
+> func foo(x) {}
+
+  Note: Previous definition in other.dachs (at other.dachs:2:6)
+
+> func foo() {}
+
+  Note: Also defined here (at main.dachs:1:6)
+
+> func foo() {}
+`
+	have := err.Error()
+	if have != want {
+		t.Fatalf("Unexpected error message.\nwant:\n'%s'\nhave:\n'%s'", want, have)
+	}
+}
+
+type testCustomError struct {
+	code int
+}
+
+func (err *testCustomError) Error() string {
+	return fmt.Sprintf("This is error text: %d", err.code)
+}
+
+func TestUnwrapCause(t *testing.T) {
+	src := NewDummySource("abc")
+	s := Pos{0, 1, 1, src}
+	e := Pos{2, 1, 3, src}
+
+	for _, tc := range []struct {
+		what string
+		err  *Error
+		want string
+	}{
+		{"WithPos", WithPos(s, io.ErrUnexpectedEOF), "Error: unexpected EOF (at <dummy>:1:1)\n\n> abc\n"},
+		{"WithRange", WithRange(s, e, io.ErrUnexpectedEOF), "Error: unexpected EOF (at <dummy>:1:1)\n\n> abc\n"},
+		{"Note", Note(io.ErrUnexpectedEOF, "note"), "Error: unexpected EOF\n  Note: note"},
+		{"Notef", Notef(io.ErrUnexpectedEOF, "note %d", 42), "Error: unexpected EOF\n  Note: note 42"},
+		{"NoteIn", NoteIn(s, e, io.ErrUnexpectedEOF, "note"), "Error: unexpected EOF (at <dummy>:1:1)\n\n> abc\n\n  Note: note"},
+		{"NoteAt", NoteAt(s, io.ErrUnexpectedEOF, "note"), "Error: unexpected EOF (at <dummy>:1:1)\n\n> abc\n\n  Note: note"},
+		{"nested", Note(WithPos(s, io.ErrUnexpectedEOF), "note"), "Error: unexpected EOF (at <dummy>:1:1)\n\n> abc\n\n  Note: note"},
+		{"wrapped by fmt.Errorf", WithPos(s, fmt.Errorf("while parsing: %w", io.ErrUnexpectedEOF)), "Error: while parsing: unexpected EOF (at <dummy>:1:1)\n\n> abc\n"},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			if !errors.Is(tc.err, io.ErrUnexpectedEOF) {
+				t.Error("errors.Is should find the original error")
+			}
+			if have := tc.err.Error(); have != tc.want {
+				t.Errorf("Unexpected error message.\nwant:\n'%s'\nhave:\n'%s'", tc.want, have)
+			}
+		})
+	}
+
+	err := NoteAt(s, &testCustomError{42}, "note")
+	var custom *testCustomError
+	if !errors.As(err, &custom) || custom.code != 42 {
+		t.Fatal("errors.As should find the original error:", custom)
+	}
+
+	if ErrorAt(s, "text").Unwrap() != nil {
+		t.Fatal("Error not made from another error should not have cause")
+	}
+}
@@ -15,7 +15,7 @@ function main() {
       "test")
 }`
 
-func ExampleErrorWithRange() {
+func Example_errorWithRange() {
 	// At first you should gain entire source as *Source instance.
 
 	src := NewDummySource(code)
@@ -32,8 +32,8 @@ func ExampleErrorWithRange() {
 	}
 	end := Pos{
 		Offset: 116,
-		Line:   9,
-		Column: 12,
+		Line:   8,
+		Column: 13,
 		File:   src,
 	}
 
@@ -63,9 +63,37 @@ func ExampleErrorWithRange() {
 	// Directly writes the error message into given file.
 	// This supports Windows. Useful to output from stdout or stderr.
 	err.PrintToFile(os.Stdout)
+	// Output:
+	// Error: Calling 'foo' with wrong number of argument (at <dummy>:6:7)
+	//
+	// >   foo(true,
+	// >       42,
+	// >       "test")
+	//
+	//   Note: Defined with 1 parameter (at <dummy>:1:10)
+	//
+	// > function foo(x: bool): int {
+	//
+	//   Note: 'foo' was defined as 'bool -> int' (at <dummy>:1:10)
+	//
+	// > function foo(x: bool): int {
+	//
+	// Error: Calling 'foo' with wrong number of argument (at <dummy>:6:7)
+	//
+	// >   foo(true,
+	// >       42,
+	// >       "test")
+	//
+	//   Note: Defined with 1 parameter (at <dummy>:1:10)
+	//
+	// > function foo(x: bool): int {
+	//
+	//   Note: 'foo' was defined as 'bool -> int' (at <dummy>:1:10)
+	//
+	// > function foo(x: bool): int {
 }
 
-func ExampleErrorWithOnePos() {
+func Example_errorWithOnePos() {
 	src := NewDummySource(code)
 
 	pos := Pos{
@@ -81,4 +109,8 @@ func ExampleErrorWithOnePos() {
 
 	// In this case, line snippet is shown in error message. `pos.Line` is used to get line from source text.
 	fmt.Println(err)
+	// Output:
+	// Error: Calling 'foo' with wrong number of argument (at <dummy>:6:7)
+	//
+	// >   foo(true,
 }
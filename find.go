@@ -0,0 +1,170 @@
+package locerr
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// walk visits errors in the chain of err in depth-first order until f returns true. Both
+// Unwrap() error and Unwrap() []error (e.g. errors.Join) are followed.
+func walk(err error, f func(error) bool) bool {
+	for err != nil {
+		if f(err) {
+			return true
+		}
+		switch e := err.(type) {
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, err := range e.Unwrap() {
+				if walk(err, f) {
+					return true
+				}
+			}
+			return false
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+func find(err error, pred func(*Error) bool) *Error {
+	var found *Error
+	walk(err, func(err error) bool {
+		if e, ok := err.(*Error); ok && pred(e) {
+			found = e
+			return true
+		}
+		return false
+	})
+	return found
+}
+
+// Find finds the first locerr.Error in the chain of the error. The chain is followed through
+// Unwrap() error and Unwrap() []error methods. It returns nil when no locerr.Error is found.
+func Find(err error) *Error {
+	return find(err, func(*Error) bool { return true })
+}
+
+// PosOf returns the position of the first locerr.Error which has a position in the chain of the
+// error. The second return value is false when no position is found.
+func PosOf(err error) (Pos, bool) {
+	e := find(err, func(e *Error) bool { return e.Start.File != nil })
+	if e == nil {
+		return Pos{}, false
+	}
+	return e.Start, true
+}
+
+// addContext returns the error c with the context added by outer error. outer is the message of
+// the outer error and inner is the error wrapped by it. c is a resolved error of inner. notes are
+// notes of the outer error. It returns nil when outer does not end with the message of inner since
+// the context cannot be separated from the message.
+func addContext(outer string, inner error, c *Error, notes []Annotation) *Error {
+	msg := inner.Error()
+	if !strings.HasSuffix(outer, msg) {
+		return nil
+	}
+	prefix := strings.TrimSuffix(outer, msg)
+	if prefix == "" && len(notes) == 0 {
+		return c
+	}
+	e := *c
+	e.Message = prefix + c.Message
+	e.Notes = append(append([]Annotation{}, c.Notes...), notes...)
+	return &e
+}
+
+// resolve returns a locerr.Error which represents the whole error with the richest location
+// information found in its chain. Messages and notes of outer errors are kept. For example,
+// fmt.Errorf("context: %w", err) is resolved to err whose message is prefixed with "context: ". It
+// returns nil when the error cannot be represented by one locerr.Error.
+func resolve(err error) *Error {
+	switch x := err.(type) {
+	case *Error:
+		if x.Start.File != nil || x.cause == nil {
+			return x
+		}
+		c := resolve(x.cause)
+		if c == nil || c.Start.File == nil {
+			return x // Cause has no richer information
+		}
+		if e := addContext(x.Message, x.cause, c, x.Notes); e != nil {
+			if e.Code == "" {
+				e.Code = x.Code
+			}
+			return e
+		}
+		return x
+	case interface{ Unwrap() []error }:
+		return nil
+	case interface{ Unwrap() error }:
+		inner := x.Unwrap()
+		if inner == nil {
+			return nil
+		}
+		c := resolve(inner)
+		if c == nil {
+			return nil
+		}
+		return addContext(err.Error(), inner, c, nil)
+	default:
+		return nil
+	}
+}
+
+// render writes the message of the error with the richest location information found in its
+// chain. Each error joined by errors.Join is rendered separately.
+func render(w io.Writer, err error) {
+	if e := resolve(err); e != nil {
+		e.WriteMessage(w)
+		return
+	}
+	for e := err; e != nil; {
+		switch x := e.(type) {
+		case *ErrorList:
+			x.WriteMessage(w)
+			return
+		case interface{ Unwrap() []error }:
+			var buf bytes.Buffer
+			first := true
+			for _, c := range x.Unwrap() {
+				if c == nil {
+					continue
+				}
+				if !first {
+					fmt.Fprint(w, "\n\n")
+				}
+				first = false
+				buf.Reset()
+				render(&buf, c)
+				w.Write(bytes.TrimRight(buf.Bytes(), "\n"))
+			}
+			return
+		case *Error:
+			e = nil // Rendering only this error would lose messages of outer errors
+		case interface{ Unwrap() error }:
+			e = x.Unwrap()
+		default:
+			e = nil
+		}
+	}
+	fmt.Fprint(w, err.Error())
+}
+
+// Print writes the error message of any error to the given writer with the richest location
+// information found in the chain of the error. When a locerr.Error is found in the chain, its
+// message is written. Context added by outer errors such as fmt.Errorf("context: %w", err) is kept
+// in the message and notes of outer locerr.Error are kept as well. When the context cannot be
+// separated from the message of the wrapped error, the message of err is written as-is. When errors are joined by errors.Join, each of them is written separately
+// with a blank line between them. When no locerr.Error is found, the message of err is written
+// as-is.
+func Print(w io.Writer, err error) {
+	if err == nil {
+		return
+	}
+	render(w, err)
+}
@@ -0,0 +1,101 @@
+package locerr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestFindAndPosOf(t *testing.T) {
+	src := NewDummySource("abc\ndef")
+	pos := src.PosAt(4)
+	located := ErrorAt(pos, "located")
+	unlocated := NewError("unlocated")
+
+	for _, tc := range []struct {
+		what  string
+		err   error
+		found *Error
+		pos   bool
+	}{
+		{"itself", located, located, true},
+		{"wrapped", fmt.Errorf("context: %w", located), located, true},
+		{"wrapped twice", fmt.Errorf("outer: %w", fmt.Errorf("inner: %w", located)), located, true},
+		{"joined", errors.Join(io.EOF, fmt.Errorf("context: %w", located)), located, true},
+		{"without position", fmt.Errorf("context: %w", unlocated), unlocated, false},
+		{"position preferred", errors.Join(unlocated, located), unlocated, true},
+		{"not found", fmt.Errorf("context: %w", io.EOF), nil, false},
+		{"nil", nil, nil, false},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			if have := Find(tc.err); have != tc.found {
+				t.Errorf("Find() should return %v but %v", tc.found, have)
+			}
+			p, ok := PosOf(tc.err)
+			if ok != tc.pos {
+				t.Fatalf("PosOf() should return %v", tc.pos)
+			}
+			if ok && p != pos {
+				t.Errorf("PosOf() should return %s but %s", pos, p)
+			}
+		})
+	}
+}
+
+func TestPrint(t *testing.T) {
+	src := NewDummySource("abc\ndef")
+	located := ErrorAt(src.PosAt(4), "located")
+
+	for _, tc := range []struct {
+		what string
+		err  error
+		want string
+	}{
+		{"locerr.Error", located, "Error: located (at <dummy>:2:1)\n\n> def\n"},
+		{"wrapped", fmt.Errorf("context: %w", located), "Error: context: located (at <dummy>:2:1)\n\n> def\n"},
+		{
+			"wrapped twice",
+			fmt.Errorf("outer: %w", fmt.Errorf("inner: %w", located)),
+			"Error: outer: inner: located (at <dummy>:2:1)\n\n> def\n",
+		},
+		{"without position", fmt.Errorf("context: %w", NewError("unlocated")), "Error: context: unlocated"},
+		{
+			"context after message",
+			fmt.Errorf("%w (context)", located),
+			"Error: located (at <dummy>:2:1)\n\n> def\n (context)",
+		},
+		{
+			"locerr.Error without position wrapping located error",
+			Note(fmt.Errorf("context: %w", located), "outer note"),
+			"Error: context: located (at <dummy>:2:1)\n\n> def\n\n  Note: outer note",
+		},
+		{
+			"notes of both errors",
+			Note(fmt.Errorf("context: %w", ErrorAt(src.PosAt(4), "located").Note("inner note")), "outer note"),
+			"Error: context: located (at <dummy>:2:1)\n\n> def\n\n  Note: inner note\n  Note: outer note",
+		},
+		{"other error", io.EOF, "EOF"},
+		{
+			"joined",
+			errors.Join(located, io.EOF, fmt.Errorf("context: %w", located)),
+			"Error: located (at <dummy>:2:1)\n\n> def\n\nEOF\n\nError: context: located (at <dummy>:2:1)\n\n> def",
+		},
+		{
+			"joined in joined",
+			fmt.Errorf("context: %w", errors.Join(NewError("first"), errors.Join(nil, NewError("second")))),
+			"Error: first\n\nError: second",
+		},
+		{"error list", &ErrorList{Errors: []*Error{located}}, "Error: located (at <dummy>:2:1)\n\n> def\n\n1 error"},
+		{"nil", nil, ""},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			var buf bytes.Buffer
+			Print(&buf, tc.err)
+			if have := buf.String(); have != tc.want {
+				t.Fatalf("Unexpected output.\nwant:\n'%s'\nhave:\n'%s'", tc.want, have)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package locerr
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// diffContext is the number of context lines around changes in unified diff.
+const diffContext = 3
+
+// FixResult is the result of applying suggestions of errors to a source.
+type FixResult struct {
+	// Source is the original source.
+	Source *Source
+	// Code is the code after applying suggestions.
+	Code []byte
+	// Applied is the list of applied suggestions in offset order.
+	Applied []Suggestion
+	// Conflicts is the list of warnings for suggestions which were not applied because they overlap
+	// with other suggestions or are out of the source.
+	Conflicts []*Error
+}
+
+// ApplyFixes applies suggestions attached to the errors to the source. Suggestions for other
+// sources are ignored. Suggestions are applied in offset order. When a suggestion overlaps with a
+// suggestion already applied, it is not applied and reported in Conflicts. Identical suggestions
+// are applied only once. The source itself is not modified.
+func ApplyFixes(src *Source, errs []*Error) *FixResult {
+	res := &FixResult{Source: src}
+
+	edits := []Suggestion{}
+	for _, err := range errs {
+		for _, s := range err.Suggestions {
+			if sameFile(s.Range.Start.File, src) {
+				edits = append(edits, s)
+			}
+		}
+	}
+	sort.SliceStable(edits, func(i, j int) bool {
+		si, ei := edits[i].span()
+		sj, ej := edits[j].span()
+		if si != sj {
+			return si < sj
+		}
+		return ei < ej
+	})
+
+	var last *Suggestion
+	for i := range edits {
+		s := &edits[i]
+		start, end := s.span()
+		if start < 0 || len(src.Code) < end {
+			res.Conflicts = append(res.Conflicts, WarningIn(s.Range.Start, s.Range.End, "Suggestion is out of the source and was not applied"))
+			continue
+		}
+		if last != nil {
+			ls, le := last.span()
+			if ls == start && le == end && last.Replacement == s.Replacement {
+				continue // Duplicate
+			}
+			// Two insertions at the same offset conflict since their order is ambiguous
+			if start < le || start == end && ls == le && ls == start {
+				w := WarningfIn(s.Range.Start, s.Range.End, "Suggestion %q conflicts with other suggestion and was not applied", s.description())
+				res.Conflicts = append(res.Conflicts, w.NotefIn(last.Range.Start, last.Range.End, "Suggestion %q was applied", last.description()))
+				continue
+			}
+		}
+		res.Applied = append(res.Applied, *s)
+		last = s
+	}
+
+	res.Code = applyEdits(src.Code, 0, res.Applied)
+	return res
+}
+
+// applyEdits applies sorted and non-overlapping edits to the code which starts at the base offset
+// in the source.
+func applyEdits(code []byte, base int, edits []Suggestion) []byte {
+	b := make([]byte, 0, len(code))
+	prev := 0
+	for i := range edits {
+		start, end := edits[i].span()
+		b = append(b, code[prev:start-base]...)
+		b = append(b, edits[i].Replacement...)
+		prev = end - base
+	}
+	return append(b, code[prev:]...)
+}
+
+// Changed returns whether the code was changed by applying suggestions.
+func (r *FixResult) Changed() bool {
+	return !bytes.Equal(r.Source.Code, r.Code)
+}
+
+// splitLines splits the code into lines. Each line contains its newline except for the last line
+// when the code does not end with newline.
+func splitLines(code []byte) [][]byte {
+	lines := [][]byte{}
+	for len(code) > 0 {
+		i := bytes.IndexByte(code, '\n') + 1
+		if i == 0 {
+			i = len(code)
+		}
+		lines = append(lines, code[:i])
+		code = code[i:]
+	}
+	return lines
+}
+
+// change is a set of edits which replaces old lines in [start, end) with new lines.
+type change struct {
+	start int
+	end   int
+	lines [][]byte
+}
+
+// changes groups applied edits by lines they touch. Edits on the same or adjacent lines are grouped
+// into one change.
+func (r *FixResult) changes() []change {
+	src := r.Source
+	// Heads of lines split by splitLines
+	heads := src.lineIndex()
+	if n := len(src.Code); n == 0 || src.Code[n-1] == '\n' {
+		// Source has no line after the last newline
+		heads = heads[:len(heads)-1]
+	}
+	lineOf := func(offset int) int {
+		if offset >= len(src.Code) && (len(heads) == 0 || src.Code[len(src.Code)-1] == '\n') {
+			return len(heads)
+		}
+		return sort.SearchInts(heads, offset+1) - 1
+	}
+	headOf := func(line int) int {
+		if line < len(heads) {
+			return heads[line]
+		}
+		return len(src.Code)
+	}
+
+	cs := []change{}
+	groups := [][]Suggestion{}
+	for _, s := range r.Applied {
+		start, end := s.span()
+		first := lineOf(start)
+		last := first + 1
+		if end > start {
+			last = lineOf(end-1) + 1
+		} else if start == headOf(first) && strings.HasSuffix(s.Replacement, "\n") {
+			last = first // Only new lines are inserted before the line
+		}
+		if last > len(heads) {
+			last = len(heads)
+		}
+		if n := len(cs); n > 0 && first <= cs[n-1].end {
+			if last > cs[n-1].end {
+				cs[n-1].end = last
+			}
+			groups[n-1] = append(groups[n-1], s)
+			continue
+		}
+		cs = append(cs, change{start: first, end: last})
+		groups = append(groups, []Suggestion{s})
+	}
+
+	for i := 0; i < len(cs); i++ {
+		c := &cs[i]
+		base := headOf(c.start)
+		for {
+			old := src.Code[base:headOf(c.end)]
+			code := applyEdits(old, base, groups[i])
+			if bytes.Equal(old, code) {
+				// Edits in the change do not modify code
+				cs = append(cs[:i], cs[i+1:]...)
+				groups = append(groups[:i], groups[i+1:]...)
+				i--
+				break
+			}
+			if len(code) == 0 || code[len(code)-1] == '\n' || c.end >= len(heads) {
+				c.lines = splitLines(code)
+				break
+			}
+			// Newline at the end of the change was removed. The next line is joined to the change
+			c.end++
+			if i+1 < len(cs) && cs[i+1].start <= c.end {
+				if cs[i+1].end > c.end {
+					c.end = cs[i+1].end
+				}
+				groups[i] = append(groups[i], groups[i+1]...)
+				cs = append(cs[:i+1], cs[i+2:]...)
+				groups = append(groups[:i+1], groups[i+2:]...)
+			}
+		}
+	}
+	return cs
+}
+
+// Diff returns the unified diff between the original code and the patched code. Both file names
+// in the header are the path of the source. Empty diff is returned when nothing was changed.
+func (r *FixResult) Diff() []byte {
+	if !r.Changed() {
+		return nil
+	}
+
+	var b bytes.Buffer
+	old := splitLines(r.Source.Code)
+	writeLine := func(prefix byte, l []byte) {
+		b.WriteByte(prefix)
+		b.Write(l)
+		if len(l) == 0 || l[len(l)-1] != '\n' {
+			b.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+	hunkRange := func(start, count int) string {
+		if count == 0 {
+			return fmt.Sprintf("%d,0", start)
+		}
+		if count == 1 {
+			return fmt.Sprint(start + 1)
+		}
+		return fmt.Sprintf("%d,%d", start+1, count)
+	}
+
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", r.Source.Path, r.Source.Path)
+
+	cs := r.changes()
+	delta := 0 // Difference of line numbers between old and new code
+	for i := 0; i < len(cs); {
+		// Collect changes whose contexts overlap into one hunk
+		j := i + 1
+		for j < len(cs) && cs[j].start-cs[j-1].end <= diffContext*2 {
+			j++
+		}
+
+		start := cs[i].start - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := cs[j-1].end + diffContext
+		if end > len(old) {
+			end = len(old)
+		}
+		added := 0
+		for _, c := range cs[i:j] {
+			added += len(c.lines) - (c.end - c.start)
+		}
+		oldLen := end - start
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(start, oldLen), hunkRange(start+delta, oldLen+added))
+
+		l := start
+		for _, c := range cs[i:j] {
+			for ; l < c.start; l++ {
+				writeLine(' ', old[l])
+			}
+			for ; l < c.end; l++ {
+				writeLine('-', old[l])
+			}
+			for _, n := range c.lines {
+				writeLine('+', n)
+			}
+		}
+		for ; l < end; l++ {
+			writeLine(' ', old[l])
+		}
+
+		delta += added
+		i = j
+	}
+
+	return b.Bytes()
+}
@@ -0,0 +1,219 @@
+package locerr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyFixes(t *testing.T) {
+	src := NewDummySource("foo(a b)\nbar(c d)\n")
+	other := NewDummySource("other")
+	other.Path = "other"
+	at := func(s, e int) Range {
+		return Range{src.PosAt(s), src.PosAt(e)}
+	}
+
+	errs := []*Error{
+		NewError("second").Suggest(at(14, 15), ", ", ""),
+		NewError("first").Suggest(at(5, 6), ", ", "").Suggest(at(5, 6), ", ", ""),
+		NewError("overlap").Suggest(at(5, 8), "x", "overlapping"),
+		NewError("insert").Suggest(Range{Start: src.PosAt(9)}, "// ", ""),
+		NewError("insert at same offset").Suggest(Range{Start: src.PosAt(9)}, "/* */", ""),
+		NewError("other file").Suggest(Range{Start: other.PosAt(0)}, "x", ""),
+	}
+	res := ApplyFixes(src, errs)
+
+	if want, have := "foo(a, b)\n// bar(c, d)\n", string(res.Code); have != want {
+		t.Fatalf("Unexpected code.\nwant:\n'%s'\nhave:\n'%s'", want, have)
+	}
+	if !res.Changed() {
+		t.Fatal("Code should be changed")
+	}
+	if string(src.Code) != "foo(a b)\nbar(c d)\n" {
+		t.Fatal("Source should not be modified:", string(src.Code))
+	}
+	if len(res.Applied) != 3 {
+		t.Fatal("Unexpected applied suggestions:", res.Applied)
+	}
+	if len(res.Conflicts) != 2 {
+		t.Fatal("Unexpected conflicts:", res.Conflicts)
+	}
+	for i, want := range []string{
+		"Warning: Suggestion \"overlapping\" conflicts with other suggestion and was not applied (at <dummy>:1:6)",
+		"Warning: Suggestion \"insert `/* */`\" conflicts with other suggestion and was not applied (at <dummy>:2:1)",
+	} {
+		if have := res.Conflicts[i].Error(); !strings.HasPrefix(have, want) {
+			t.Errorf("Unexpected conflict at %d.\nwant:\n'%s'\nhave:\n'%s'", i, want, have)
+		}
+		if res.Conflicts[i].Severity != SeverityWarning {
+			t.Errorf("Conflict at %d should be warning", i)
+		}
+	}
+}
+
+func TestApplyFixesOutOfSource(t *testing.T) {
+	src := NewDummySource("abc")
+	res := ApplyFixes(src, []*Error{
+		NewError("error").Suggest(Range{Pos{1, 1, 2, src}, Pos{10, 1, 11, src}}, "x", ""),
+	})
+	if res.Changed() || len(res.Applied) != 0 || len(res.Conflicts) != 1 {
+		t.Fatal("Suggestion out of source should not be applied:", res)
+	}
+	if res.Diff() != nil {
+		t.Fatal("Diff should be empty:", string(res.Diff()))
+	}
+}
+
+func TestFixResultDiff(t *testing.T) {
+	lines := []string{}
+	for _, c := range "abcdefghijklmnopqrst" {
+		lines = append(lines, strings.Repeat(string(c), 3))
+	}
+	code := strings.Join(lines, "\n") + "\n"
+
+	for _, tc := range []struct {
+		what  string
+		code  string
+		edits []Suggestion
+		want  string
+	}{
+		{
+			what:  "replace in one line",
+			code:  code,
+			edits: []Suggestion{{Range: Range{Start: Pos{Offset: 21}, End: Pos{Offset: 22}}, Replacement: "F"}},
+			want: `--- test.txt
++++ test.txt
+@@ -3,7 +3,7 @@
+ ccc
+ ddd
+ eee
+-fff
++fFf
+ ggg
+ hhh
+ iii
+`,
+		},
+		{
+			what: "hunks",
+			code: code,
+			edits: []Suggestion{
+				{Range: Range{Start: Pos{Offset: 0}}, Replacement: "// "},
+				{Range: Range{Start: Pos{Offset: 28}, End: Pos{Offset: 32}}, Replacement: ""},
+				{Range: Range{Start: Pos{Offset: 76}}, Replacement: "xxx\n"},
+			},
+			want: `--- test.txt
++++ test.txt
+@@ -1,11 +1,10 @@
+-aaa
++// aaa
+ bbb
+ ccc
+ ddd
+ eee
+ fff
+ ggg
+-hhh
+ iii
+ jjj
+ kkk
+@@ -17,4 +16,5 @@
+ qqq
+ rrr
+ sss
++xxx
+ ttt
+`,
+		},
+		{
+			what:  "join lines",
+			code:  "foo\nbar\nbaz\n",
+			edits: []Suggestion{{Range: Range{Start: Pos{Offset: 3}, End: Pos{Offset: 4}}, Replacement: " "}},
+			want: `--- test.txt
++++ test.txt
+@@ -1,3 +1,2 @@
+-foo
+-bar
++foo bar
+ baz
+`,
+		},
+		{
+			what:  "append at end of file",
+			code:  "foo\n",
+			edits: []Suggestion{{Range: Range{Start: Pos{Offset: 4}}, Replacement: "bar\n"}},
+			want: `--- test.txt
++++ test.txt
+@@ -1 +1,2 @@
+ foo
++bar
+`,
+		},
+		{
+			what:  "no newline at end of file",
+			code:  "foo\nbar",
+			edits: []Suggestion{{Range: Range{Start: Pos{Offset: 7}}, Replacement: ";"}},
+			want: `--- test.txt
++++ test.txt
+@@ -1,2 +1,2 @@
+ foo
+-bar
+\ No newline at end of file
++bar;
+\ No newline at end of file
+`,
+		},
+		{
+			what:  "add newline at end of file",
+			code:  "foo",
+			edits: []Suggestion{{Range: Range{Start: Pos{Offset: 3}}, Replacement: "\n"}},
+			want: `--- test.txt
++++ test.txt
+@@ -1 +1 @@
+-foo
+\ No newline at end of file
++foo
+`,
+		},
+		{
+			what:  "empty source",
+			code:  "",
+			edits: []Suggestion{{Range: Range{Start: Pos{Offset: 0}}, Replacement: "foo\n"}},
+			want: `--- test.txt
++++ test.txt
+@@ -0,0 +1 @@
++foo
+`,
+		},
+		{
+			what:  "delete all",
+			code:  "foo\nbar\n",
+			edits: []Suggestion{{Range: Range{Start: Pos{Offset: 0}, End: Pos{Offset: 8}}, Replacement: ""}},
+			want: `--- test.txt
++++ test.txt
+@@ -1,2 +0,0 @@
+-foo
+-bar
+`,
+		},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			src := &Source{Path: "test.txt", Code: []byte(tc.code)}
+			err := NewError("error")
+			for _, e := range tc.edits {
+				r := Range{Start: src.PosAt(e.Range.Start.Offset)}
+				if e.Range.End != (Pos{}) {
+					r.End = src.PosAt(e.Range.End.Offset)
+				}
+				err.Suggest(r, e.Replacement, "")
+			}
+			res := ApplyFixes(src, []*Error{err})
+			if len(res.Conflicts) > 0 {
+				t.Fatal("Unexpected conflicts:", res.Conflicts)
+			}
+			if have := string(res.Diff()); have != tc.want {
+				t.Fatalf("Unexpected diff.\nwant:\n'%s'\nhave:\n'%s'", tc.want, have)
+			}
+		})
+	}
+}
@@ -6,29 +6,12 @@ import (
 	"strings"
 )
 
-func offsetPos(src *locerr.Source, offset int) locerr.Pos {
-	o, l, c, end := 0, 1, 1, len(src.Code)
-	for o != end {
-		if o == offset {
-			return locerr.Pos{o, l, c, src}
-		}
-		if src.Code[o] == '\n' {
-			l++
-			c = 1
-		} else {
-			c++
-		}
-		o++
-	}
-	return locerr.Pos{o, l, c, src}
-}
-
 // Fuzz do fuzzing test using go-fuzz
 func Fuzz(data []byte) int {
 	src := locerr.NewDummySource(string(data))
 	len := len(data)
 	if len == 0 {
-		p := locerr.Pos{0, 1, 1, src}
+		p := src.PosAt(0)
 		return fuzz(src, p, p)
 	}
 
@@ -36,9 +19,9 @@ func Fuzz(data []byte) int {
 	if len > 1 {
 		o = rand.Intn(len - 1)
 	}
-	s := offsetPos(src, o)
+	s := src.PosAt(o)
 	o = rand.Intn(len-o) + o
-	e := offsetPos(src, o)
+	e := src.PosAt(o)
 	return fuzz(src, s, e)
 }
 
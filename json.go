@@ -0,0 +1,219 @@
+package locerr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// MarshalText encodes the severity as its name such as "error".
+func (s Severity) MarshalText() ([]byte, error) {
+	switch s {
+	case SeverityError, SeverityWarning, SeverityInfo, SeverityHint:
+		return []byte(s.String()), nil
+	default:
+		return nil, fmt.Errorf("unknown severity %d", int(s))
+	}
+}
+
+// UnmarshalText decodes the name of severity such as "error".
+func (s *Severity) UnmarshalText(text []byte) error {
+	for _, sev := range []Severity{SeverityError, SeverityWarning, SeverityInfo, SeverityHint} {
+		if string(text) == sev.String() {
+			*s = sev
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown severity %q", text)
+}
+
+// MarshalText encodes the annotation kind as its name such as "note".
+func (k AnnotationKind) MarshalText() ([]byte, error) {
+	switch k {
+	case AnnotationNote, AnnotationHelp:
+		return []byte(k.String()), nil
+	default:
+		return nil, fmt.Errorf("unknown annotation kind %d", int(k))
+	}
+}
+
+// UnmarshalText decodes the name of annotation kind such as "note".
+func (k *AnnotationKind) UnmarshalText(text []byte) error {
+	for _, kind := range []AnnotationKind{AnnotationNote, AnnotationHelp} {
+		if string(text) == kind.String() {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown annotation kind %q", text)
+}
+
+type jsonPos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+	Offset int `json:"offset"`
+}
+
+// jsonRange is a location embedded in JSON objects. Start is omitted when the location has no
+// position and End is omitted when it has no range.
+type jsonRange struct {
+	File  string   `json:"file,omitempty"`
+	Start *jsonPos `json:"start,omitempty"`
+	End   *jsonPos `json:"end,omitempty"`
+}
+
+type jsonNote struct {
+	Kind    AnnotationKind `json:"kind"`
+	Message string         `json:"message"`
+	jsonRange
+}
+
+type jsonSuggestion struct {
+	Replacement string `json:"replacement"`
+	Description string `json:"description,omitempty"`
+	jsonRange
+}
+
+type jsonError struct {
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code,omitempty"`
+	Message  string   `json:"message"`
+	jsonRange
+	Notes       []jsonNote       `json:"notes,omitempty"`
+	Suggestions []jsonSuggestion `json:"suggestions,omitempty"`
+}
+
+func newJSONRange(start, end Pos) jsonRange {
+	var r jsonRange
+	if start.File == nil {
+		return r
+	}
+	r.File = start.File.Path
+	r.Start = &jsonPos{start.Line, start.Column, start.Offset}
+	if end.File != nil {
+		r.End = &jsonPos{end.Line, end.Column, end.Offset}
+	}
+	return r
+}
+
+// sources makes sources for paths in JSON. Decoded positions in the same file share one source.
+type sources map[string]*Source
+
+func (srcs sources) positions(r *jsonRange) (Pos, Pos) {
+	if r.Start == nil {
+		return Pos{}, Pos{}
+	}
+	src, ok := srcs[r.File]
+	if !ok {
+		src = &Source{Path: r.File}
+		srcs[r.File] = src
+	}
+	start := Pos{Offset: r.Start.Offset, Line: r.Start.Line, Column: r.Start.Column, File: src}
+	if r.End == nil {
+		return start, Pos{}
+	}
+	return start, Pos{Offset: r.End.Offset, Line: r.End.Line, Column: r.End.Column, File: src}
+}
+
+// MarshalJSON encodes the error as a JSON object. Source code is not included. The schema is:
+//
+//	{
+//	  "severity": "error",           // "error", "warning", "info" or "hint"
+//	  "code": "E0042",               // Omitted when the error has no code
+//	  "message": "Type mismatch",
+//	  "file": "/path/to/file",       // Omitted when the error has no position
+//	  "start": {                     // Omitted when the error has no position
+//	    "line": 3,                   // Starts from 1
+//	    "column": 5,                 // Starts from 1. Counted in bytes
+//	    "offset": 24                 // Starts from 0. Counted in bytes
+//	  },
+//	  "end": {...},                  // Same as "start". Omitted when the error has no range
+//	  "notes": [                     // Omitted when the error has no note
+//	    {
+//	      "kind": "note",            // "note" or "help"
+//	      "message": "Defined here",
+//	      "file": "/path/to/file",   // "file", "start" and "end" are the same as the error
+//	      "start": {...},
+//	      "end": {...}
+//	    }
+//	  ],
+//	  "suggestions": [               // Omitted when the error has no suggestion
+//	    {
+//	      "replacement": ";",
+//	      "description": "add semicolon", // Omitted when it is empty
+//	      "file": "/path/to/file",   // "file", "start" and "end" are the same as the error
+//	      "start": {...},
+//	      "end": {...}
+//	    }
+//	  ]
+//	}
+func (err *Error) MarshalJSON() ([]byte, error) {
+	j := jsonError{
+		Severity:  err.Severity,
+		Code:      err.Code,
+		Message:   err.Message,
+		jsonRange: newJSONRange(err.Start, err.End),
+	}
+	for _, n := range err.Notes {
+		j.Notes = append(j.Notes, jsonNote{n.Kind, n.Message, newJSONRange(n.Start, n.End)})
+	}
+	for _, s := range err.Suggestions {
+		j.Suggestions = append(j.Suggestions, jsonSuggestion{s.Replacement, s.Description, newJSONRange(s.Range.Start, s.Range.End)})
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false) // Paths such as "<stdin>" should be kept as-is
+	if e := enc.Encode(&j); e != nil {
+		return nil, e
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// UnmarshalJSON decodes the JSON object encoded by MarshalJSON. Since source code is not included
+// in JSON, sources of decoded positions only have their paths. Positions in the same file share
+// one source.
+func (err *Error) UnmarshalJSON(data []byte) error {
+	var j jsonError
+	if e := json.Unmarshal(data, &j); e != nil {
+		return e
+	}
+
+	srcs := sources{}
+	*err = Error{Severity: j.Severity, Code: j.Code, Message: j.Message}
+	err.Start, err.End = srcs.positions(&j.jsonRange)
+	for i := range j.Notes {
+		n := &j.Notes[i]
+		start, end := srcs.positions(&n.jsonRange)
+		err.Notes = append(err.Notes, Annotation{Kind: n.Kind, Message: n.Message, Start: start, End: end})
+	}
+	for i := range j.Suggestions {
+		s := &j.Suggestions[i]
+		start, end := srcs.positions(&s.jsonRange)
+		err.Suggestions = append(err.Suggestions, Suggestion{Range: Range{start, end}, Replacement: s.Replacement, Description: s.Description})
+	}
+	return nil
+}
+
+// JSONLinesWriter writes errors in JSON Lines format. Each error is written as one line of JSON
+// object as soon as it is reported. See Error.MarshalJSON for the schema. It is safe for
+// concurrent use.
+type JSONLinesWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONLinesWriter makes a new writer which writes errors to the given writer.
+func NewJSONLinesWriter(w io.Writer) *JSONLinesWriter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &JSONLinesWriter{enc: enc}
+}
+
+// Report writes the error as one line of JSON.
+func (w *JSONLinesWriter) Report(err *Error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.enc.Encode(err)
+}
@@ -0,0 +1,160 @@
+package locerr
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestErrorMarshalJSON(t *testing.T) {
+	src := NewDummySource("let x = 1\nfoo(a b)\n")
+	other := NewDummySource("def")
+	other.Path = "other.txt"
+
+	for _, tc := range []struct {
+		what string
+		err  *Error
+		want string
+	}{
+		{
+			"without position",
+			NewError("text"),
+			`{"severity":"error","message":"text"}`,
+		},
+		{
+			"with position",
+			WarningAt(src.PosAt(4), "text").WithCode("W001"),
+			`{"severity":"warning","code":"W001","message":"text","file":"<dummy>","start":{"line":1,"column":5,"offset":4}}`,
+		},
+		{
+			"with range",
+			HintIn(src.PosAt(10), src.PosAt(13), "text"),
+			`{"severity":"hint","message":"text","file":"<dummy>","start":{"line":2,"column":1,"offset":10},"end":{"line":2,"column":4,"offset":13}}`,
+		},
+		{
+			"notes",
+			NewError("text").Note("note").HelpAt(other.PosAt(1), "help"),
+			`{"severity":"error","message":"text","notes":[{"kind":"note","message":"note"},{"kind":"help","message":"help","file":"other.txt","start":{"line":1,"column":2,"offset":1}}]}`,
+		},
+		{
+			"suggestions",
+			InfoAt(src.PosAt(15), "text").Suggest(Range{Start: src.PosAt(15)}, ",", "add comma").Suggest(Range{src.PosAt(14), src.PosAt(15)}, "", ""),
+			`{"severity":"info","message":"text","file":"<dummy>","start":{"line":2,"column":6,"offset":15},"suggestions":[` +
+				`{"replacement":",","description":"add comma","file":"<dummy>","start":{"line":2,"column":6,"offset":15}},` +
+				`{"replacement":"","file":"<dummy>","start":{"line":2,"column":5,"offset":14},"end":{"line":2,"column":6,"offset":15}}]}`,
+		},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			b, err := tc.err.MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if have := string(b); have != tc.want {
+				t.Fatalf("Unexpected JSON.\nwant:\n'%s'\nhave:\n'%s'", tc.want, have)
+			}
+
+			var decoded Error
+			if err := json.Unmarshal(b, &decoded); err != nil {
+				t.Fatal(err)
+			}
+			b2, err := decoded.MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if have := string(b2); have != tc.want {
+				t.Fatalf("JSON was changed after round trip.\nwant:\n'%s'\nhave:\n'%s'", tc.want, have)
+			}
+		})
+	}
+}
+
+func TestErrorUnmarshalJSON(t *testing.T) {
+	var err Error
+	input := `{"severity":"warning","code":"W001","message":"text","file":"a.txt","start":{"line":1,"column":2,"offset":1},"end":{"line":1,"column":4,"offset":3},"notes":[{"kind":"help","message":"help","file":"a.txt","start":{"line":2,"column":1,"offset":5}}]}`
+	if e := json.Unmarshal([]byte(input), &err); e != nil {
+		t.Fatal(e)
+	}
+	if err.Severity != SeverityWarning || err.Code != "W001" || err.Message != "text" {
+		t.Fatalf("Unexpected error: %+v", err)
+	}
+	if err.Start.File == nil || err.Start.File.Path != "a.txt" || err.Start.File.Code != nil {
+		t.Fatal("Unexpected source:", err.Start.File)
+	}
+	if err.End.File != err.Start.File || err.Notes[0].Start.File != err.Start.File {
+		t.Fatal("Positions in the same file should share the source")
+	}
+	if err.Start.String() != "a.txt:1:2" || err.End.Offset != 3 {
+		t.Fatal("Unexpected range:", err.Start, err.End)
+	}
+	if err.Notes[0].Kind != AnnotationHelp {
+		t.Fatal("Unexpected note:", err.Notes[0])
+	}
+
+	want := "Warning[W001]: text (at a.txt:1:2)\n  Help: help (at a.txt:2:1)"
+	if have := err.Error(); have != want {
+		t.Fatalf("Unexpected message.\nwant:\n'%s'\nhave:\n'%s'", want, have)
+	}
+
+	for _, input := range []string{
+		`{"severity":"fatal","message":"text"}`,
+		`{"severity":"error","message":"text","notes":[{"kind":"todo","message":"note"}]}`,
+		`{"severity":"error","message":42}`,
+	} {
+		if e := json.Unmarshal([]byte(input), &err); e == nil {
+			t.Errorf("Invalid JSON should cause an error: %s", input)
+		}
+	}
+}
+
+func TestSeverityMarshalText(t *testing.T) {
+	for _, s := range []Severity{SeverityError, SeverityWarning, SeverityInfo, SeverityHint} {
+		b, err := s.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded Severity
+		if err := decoded.UnmarshalText(b); err != nil {
+			t.Fatal(err)
+		}
+		if decoded != s {
+			t.Errorf("Severity %s was changed to %s after round trip", s, decoded)
+		}
+	}
+	if _, err := Severity(42).MarshalText(); err == nil {
+		t.Error("Unknown severity should cause an error")
+	}
+}
+
+func TestJSONLinesWriter(t *testing.T) {
+	src := NewDummySource("abc\ndef")
+	var buf bytes.Buffer
+	w := NewJSONLinesWriter(&buf)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := w.Report(ErrorfAt(src.PosAt(i%7), "error %d", i)); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 10 {
+		t.Fatalf("Number of lines should be 10 but %d: %q", len(lines), buf.String())
+	}
+	for _, l := range lines {
+		var err Error
+		if e := json.Unmarshal([]byte(l), &err); e != nil {
+			t.Fatal(e, l)
+		}
+		if !strings.Contains(l, `"file":"<dummy>"`) {
+			t.Fatal("Path should not be escaped:", l)
+		}
+	}
+}
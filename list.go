@@ -0,0 +1,167 @@
+package locerr
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mattn/go-colorable"
+)
+
+// ErrorList is a list of errors reported from one run such as compiling a program. It implements
+// error interface. Zero value is an empty list which has no limit.
+type ErrorList struct {
+	// Errors contained in the list.
+	Errors []*Error
+	// Limit is the maximum number of errors the list accepts. When it is not positive, the list
+	// accepts any number of errors.
+	Limit int
+	// Dropped is the number of errors which were not added because of Limit.
+	Dropped int
+}
+
+// NewErrorList makes an empty error list which accepts at most limit errors. When limit is not
+// positive, the list accepts any number of errors.
+func NewErrorList(limit int) *ErrorList {
+	return &ErrorList{Limit: limit}
+}
+
+// Add adds the error to the list. When the list already reached the limit, the error is dropped
+// and false is returned.
+func (l *ErrorList) Add(err *Error) bool {
+	if l.Limit > 0 && len(l.Errors) >= l.Limit {
+		l.Dropped++
+		return false
+	}
+	l.Errors = append(l.Errors, err)
+	return true
+}
+
+// Len returns the number of errors in the list.
+func (l *ErrorList) Len() int {
+	return len(l.Errors)
+}
+
+func compareErrors(a, b *Error) int {
+	if c := a.Start.Compare(b.Start); c != 0 {
+		return c
+	}
+	if c := a.End.Compare(b.End); c != 0 {
+		return c
+	}
+	return strings.Compare(a.Message, b.Message)
+}
+
+func sortErrors(errs []*Error) {
+	sort.SliceStable(errs, func(i, j int) bool {
+		return compareErrors(errs[i], errs[j]) < 0
+	})
+}
+
+// Sort sorts errors in the list by their positions. See Pos.Compare for the order. Errors at the
+// same position are sorted by their messages.
+func (l *ErrorList) Sort() {
+	sortErrors(l.Errors)
+}
+
+// RemoveDuplicates sorts the list and removes errors which have the same range and the same
+// message as the previous one.
+func (l *ErrorList) RemoveDuplicates() {
+	l.Sort()
+	errs := l.Errors[:0]
+	for i, err := range l.Errors {
+		if i > 0 {
+			prev := errs[len(errs)-1]
+			if compareErrors(prev, err) == 0 {
+				continue
+			}
+		}
+		errs = append(errs, err)
+	}
+	for i := len(errs); i < len(l.Errors); i++ {
+		l.Errors[i] = nil // Do not retain removed errors
+	}
+	l.Errors = errs
+}
+
+// Unwrap returns errors in the list. This makes errors.Is and errors.As work with errors in the list.
+func (l *ErrorList) Unwrap() []error {
+	errs := make([]error, 0, len(l.Errors))
+	for _, err := range l.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// Err returns nil when the list is empty. Otherwise it returns the list itself.
+func (l *ErrorList) Err() error {
+	if len(l.Errors) == 0 {
+		return nil
+	}
+	return l
+}
+
+// summary makes a summary line such as "2 errors, 1 warning".
+func summary(counts map[Severity]int, dropped int) string {
+	parts := []string{}
+	for _, s := range []Severity{SeverityError, SeverityWarning, SeverityInfo, SeverityHint} {
+		n := counts[s]
+		if n == 0 {
+			continue
+		}
+		word := s.String()
+		if n > 1 && s != SeverityInfo {
+			word += "s"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", n, word))
+	}
+	if dropped > 0 {
+		parts = append(parts, fmt.Sprintf("and %d more omitted", dropped))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// writeErrors writes messages of errors separated with a blank line.
+func writeErrors(w io.Writer, errs []*Error) {
+	var buf bytes.Buffer
+	for i, err := range errs {
+		if i > 0 {
+			fmt.Fprint(w, "\n\n")
+		}
+		buf.Reset()
+		err.WriteMessage(&buf)
+		w.Write(bytes.TrimRight(buf.Bytes(), "\n"))
+	}
+}
+
+// WriteMessage writes messages of all errors in the list followed by a summary line to the given
+// writer.
+func (l *ErrorList) WriteMessage(w io.Writer) {
+	if len(l.Errors) == 0 {
+		fmt.Fprint(w, "No error")
+		return
+	}
+	writeErrors(w, l.Errors)
+	counts := map[Severity]int{}
+	for _, err := range l.Errors {
+		counts[err.Severity]++
+	}
+	fmt.Fprint(w, "\n\n")
+	bold.Fprint(w, summary(counts, l.Dropped))
+}
+
+// Error builds error message for the list.
+func (l *ErrorList) Error() string {
+	var buf bytes.Buffer
+	l.WriteMessage(&buf)
+	return buf.String()
+}
+
+// PrintToFile prints messages of the list to the given file. This is useful on Windows because
+// Error() does not support colorful string on Windows.
+func (l *ErrorList) PrintToFile(f *os.File) {
+	l.WriteMessage(colorable.NewColorable(f))
+}
@@ -0,0 +1,175 @@
+package locerr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorListAddWithLimit(t *testing.T) {
+	l := NewErrorList(2)
+	for i, want := range []bool{true, true, false, false} {
+		if have := l.Add(Errorf("error %d", i)); have != want {
+			t.Errorf("Add() for error %d should return %v", i, want)
+		}
+	}
+	if l.Len() != 2 {
+		t.Fatal("Unexpected length:", l.Len())
+	}
+	if l.Dropped != 2 {
+		t.Fatal("Unexpected number of dropped errors:", l.Dropped)
+	}
+
+	var unlimited ErrorList
+	for i := 0; i < 100; i++ {
+		unlimited.Add(Errorf("error %d", i))
+	}
+	if unlimited.Len() != 100 || unlimited.Dropped != 0 {
+		t.Fatal("Zero value should not have limit:", unlimited.Len(), unlimited.Dropped)
+	}
+}
+
+func TestErrorListSortAndRemoveDuplicates(t *testing.T) {
+	a := NewDummySource("abc\ndef")
+	a.Path = "a"
+	b := NewDummySource("abc\ndef")
+	b.Path = "b"
+
+	var l ErrorList
+	l.Add(ErrorAt(b.PosAt(1), "b1"))
+	l.Add(ErrorAt(a.PosAt(5), "a5"))
+	l.Add(NewError("nopos"))
+	l.Add(ErrorAt(a.PosAt(1), "a1-y"))
+	l.Add(ErrorAt(a.PosAt(1), "a1-x"))
+	l.Add(ErrorAt(a.PosAt(5), "a5"))
+	l.Add(ErrorAt(b.PosAt(1), "b1"))
+
+	l.Sort()
+	want := []string{"nopos", "a1-x", "a1-y", "a5", "a5", "b1", "b1"}
+	for i, err := range l.Errors {
+		if err.Message != want[i] {
+			t.Fatalf("Error at %d should be '%s' but '%s'", i, want[i], err.Message)
+		}
+	}
+
+	l.RemoveDuplicates()
+	want = []string{"nopos", "a1-x", "a1-y", "a5", "b1"}
+	if l.Len() != len(want) {
+		t.Fatal("Unexpected length after removing duplicates:", l.Len())
+	}
+	for i, err := range l.Errors {
+		if err.Message != want[i] {
+			t.Fatalf("Error at %d should be '%s' but '%s'", i, want[i], err.Message)
+		}
+	}
+}
+
+func TestErrorListRemoveDuplicatesWithDifferentEnds(t *testing.T) {
+	src := NewDummySource("abcdef")
+
+	// Errors at the same start with different ends are not duplicates regardless of other errors
+	// sorted between them
+	for _, errs := range [][]*Error{
+		{
+			ErrorIn(src.PosAt(0), src.PosAt(1), "x"),
+			ErrorIn(src.PosAt(0), src.PosAt(2), "x"),
+		},
+		{
+			ErrorIn(src.PosAt(0), src.PosAt(1), "x"),
+			ErrorIn(src.PosAt(0), src.PosAt(1), "y"),
+			ErrorIn(src.PosAt(0), src.PosAt(2), "x"),
+		},
+	} {
+		l := ErrorList{Errors: errs}
+		n := l.Len()
+		l.RemoveDuplicates()
+		if l.Len() != n {
+			t.Fatalf("No error should be removed but %d errors remain: %v", l.Len(), l.Errors)
+		}
+	}
+
+	l := ErrorList{Errors: []*Error{
+		ErrorIn(src.PosAt(0), src.PosAt(2), "x"),
+		ErrorIn(src.PosAt(0), src.PosAt(1), "y"),
+		ErrorIn(src.PosAt(0), src.PosAt(2), "x"),
+	}}
+	l.RemoveDuplicates()
+	if l.Len() != 2 {
+		t.Fatalf("Duplicate error should be removed but %d errors remain: %v", l.Len(), l.Errors)
+	}
+}
+
+func TestErrorListMessage(t *testing.T) {
+	src := NewDummySource("abc\ndef")
+
+	l := NewErrorList(3)
+	l.Add(ErrorAt(src.PosAt(4), "error1"))
+	l.Add(NewError("error2").Note("note"))
+	l.Add(WarningIn(src.PosAt(0), src.PosAt(2), "warning"))
+	l.Add(NewError("error3"))
+
+	want := `Error: error1 (at <dummy>:2:1)
+
+> def
+
+Error: error2
+  Note: note
+
+Warning: warning (at <dummy>:1:1)
+
+> abc
+
+2 errors, 1 warning, and 1 more omitted`
+	if have := l.Error(); have != want {
+		t.Fatalf("Unexpected message.\nwant:\n'%s'\nhave:\n'%s'", want, have)
+	}
+}
+
+func TestErrorListSummary(t *testing.T) {
+	for _, tc := range []struct {
+		what string
+		errs []*Error
+		want string
+	}{
+		{"one error", []*Error{NewError("e")}, "Error: e\n\n1 error"},
+		{"info is not plural", []*Error{InfoAt(Pos{}, "i"), InfoAt(Pos{}, "i")}, "Info: i\n\nInfo: i\n\n2 info"},
+		{"hints", []*Error{HintAt(Pos{}, "h"), HintAt(Pos{}, "h")}, "Hint: h\n\nHint: h\n\n2 hints"},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			l := &ErrorList{Errors: tc.errs}
+			if have := l.Error(); have != tc.want {
+				t.Fatalf("Unexpected message.\nwant:\n'%s'\nhave:\n'%s'", tc.want, have)
+			}
+		})
+	}
+}
+
+func TestErrorListErr(t *testing.T) {
+	var l ErrorList
+	if l.Err() != nil {
+		t.Fatal("Empty list should not be an error")
+	}
+	if l.Error() != "No error" {
+		t.Fatal("Unexpected message for empty list:", l.Error())
+	}
+	l.Add(NewError("error"))
+	if l.Err() != &l {
+		t.Fatal("Non-empty list should be an error")
+	}
+}
+
+func TestErrorListUnwrap(t *testing.T) {
+	target := NewError("target")
+	l := &ErrorList{Errors: []*Error{NewError("other"), target}}
+	errs := l.Unwrap()
+	if len(errs) != 2 || errs[1] != target {
+		t.Fatal("Unexpected unwrapped errors:", errs)
+	}
+	var err error = l
+	if !errors.Is(err, target) {
+		t.Fatal("errors.Is() should find error in the list")
+	}
+	if Find(fmt.Errorf("context: %w", err)) == nil {
+		t.Fatal("Find() should find error in the list")
+	}
+}
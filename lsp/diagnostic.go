@@ -0,0 +1,122 @@
+package lsp
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/rhysd/locerr"
+)
+
+// URIFromPath converts the file path to file:// URI. Relative path is converted to absolute path.
+// When the path is already a URI such as 'untitled:Untitled-1' (for example a path converted by
+// PathFromURI), it is returned as-is.
+func URIFromPath(path string) DocumentURI {
+	if !filepath.IsAbs(path) {
+		// One-letter scheme is not checked since it is a drive letter on Windows
+		if u, err := url.Parse(path); err == nil && len(u.Scheme) > 1 {
+			return DocumentURI(path)
+		}
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	p := filepath.ToSlash(path)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p // Windows path such as C:/foo
+	}
+	return DocumentURI((&url.URL{Scheme: "file", Path: p}).String())
+}
+
+// PathFromURI converts the file:// URI to file path. When the URI is not a file:// URI, it is
+// returned as-is.
+func PathFromURI(uri DocumentURI) string {
+	u, err := url.Parse(string(uri))
+	if err != nil || u.Scheme != "file" {
+		return string(uri)
+	}
+	p := u.Path
+	if len(p) >= 3 && p[0] == '/' && p[2] == ':' {
+		p = p[1:] // Windows path such as /C:/foo
+	}
+	return filepath.FromSlash(p)
+}
+
+// NewPosition converts the position to zero-based LSP position. Character is counted in UTF-16
+// code units.
+func NewPosition(pos locerr.Pos) Position {
+	p := Position{pos.Line - 1, pos.ColumnIn(locerr.ColumnUTF16) - 1}
+	if p.Line < 0 || p.Character < 0 {
+		return Position{}
+	}
+	return p
+}
+
+// NewRange converts the range to LSP range. When end has no source, the range is empty range at
+// start.
+func NewRange(start, end locerr.Pos) Range {
+	s := NewPosition(start)
+	if end.File == nil {
+		return Range{s, s}
+	}
+	return Range{s, NewPosition(end)}
+}
+
+func newSeverity(s locerr.Severity) DiagnosticSeverity {
+	switch s {
+	case locerr.SeverityWarning:
+		return SeverityWarning
+	case locerr.SeverityInfo:
+		return SeverityInformation
+	case locerr.SeverityHint:
+		return SeverityHint
+	default:
+		return SeverityError
+	}
+}
+
+// NewDiagnostic converts the error to LSP diagnostic. Notes with positions are converted to related
+// information. Notes without positions are appended to the message since they cannot be shown as
+// related information.
+func NewDiagnostic(err *locerr.Error) Diagnostic {
+	return newDiagnostic(err, uriOfSource)
+}
+
+func uriOfSource(src *locerr.Source) DocumentURI {
+	return URIFromPath(src.Path)
+}
+
+// newDiagnostic converts the error to LSP diagnostic. uriOf returns the URI of the source of notes.
+func newDiagnostic(err *locerr.Error, uriOf func(*locerr.Source) DocumentURI) Diagnostic {
+	d := Diagnostic{
+		Range:    NewRange(err.Start, err.End),
+		Severity: newSeverity(err.Severity),
+		Code:     err.Code,
+		Message:  err.Message,
+	}
+	for _, n := range err.Notes {
+		if !n.HasPos() {
+			d.Message += "\n" + n.Kind.Label() + ": " + n.Message
+			continue
+		}
+		d.RelatedInformation = append(d.RelatedInformation, DiagnosticRelatedInformation{
+			Location: Location{uriOf(n.Start.File), NewRange(n.Start, n.End)},
+			Message:  n.Message,
+		})
+	}
+	return d
+}
+
+// NewDiagnostics converts the errors to LSP diagnostics. It returns an empty slice instead of nil
+// so that it is encoded to an empty JSON array.
+func NewDiagnostics(errs []*locerr.Error) []Diagnostic {
+	return newDiagnostics(errs, uriOfSource)
+}
+
+func newDiagnostics(errs []*locerr.Error, uriOf func(*locerr.Source) DocumentURI) []Diagnostic {
+	ds := make([]Diagnostic, 0, len(errs))
+	for _, err := range errs {
+		ds = append(ds, newDiagnostic(err, uriOf))
+	}
+	return ds
+}
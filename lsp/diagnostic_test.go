@@ -0,0 +1,145 @@
+package lsp
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/rhysd/locerr"
+)
+
+func TestURIFromPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Paths in this test are for Unix-like systems")
+	}
+	for _, tc := range []struct {
+		path string
+		uri  DocumentURI
+	}{
+		{"/path/to/file.txt", "file:///path/to/file.txt"},
+		{"/path/to/file name#1.txt", "file:///path/to/file%20name%231.txt"},
+		{"/path/to/あ.txt", "file:///path/to/%E3%81%82.txt"},
+	} {
+		if have := URIFromPath(tc.path); have != tc.uri {
+			t.Errorf("Wanted %q for %q but have %q", tc.uri, tc.path, have)
+		}
+		if have := PathFromURI(tc.uri); have != tc.path {
+			t.Errorf("Wanted %q for %q but have %q", tc.path, tc.uri, have)
+		}
+	}
+
+	abs, err := filepath.Abs("file.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := PathFromURI(URIFromPath("file.txt")); have != abs {
+		t.Errorf("Relative path should be converted to %q but have %q", abs, have)
+	}
+	if have := PathFromURI("untitled:Untitled-1"); have != "untitled:Untitled-1" {
+		t.Errorf("URI other than file:// should be returned as-is but have %q", have)
+	}
+	if have := URIFromPath("untitled:Untitled-1"); have != "untitled:Untitled-1" {
+		t.Errorf("Path which is already URI should be returned as-is but have %q", have)
+	}
+}
+
+func TestNewDiagnostic(t *testing.T) {
+	src := &locerr.Source{Path: "/path/to/test.txt", Code: []byte("let x = 1\nfoo(あ😀 b)\n")}
+	other := &locerr.Source{Path: "/path/to/other.txt", Code: []byte("abc\ndef")}
+
+	for _, tc := range []struct {
+		what string
+		err  *locerr.Error
+		want Diagnostic
+	}{
+		{
+			"ascii",
+			locerr.ErrorIn(src.PosAt(4), src.PosAt(5), "error"),
+			Diagnostic{
+				Range:    Range{Position{0, 4}, Position{0, 5}},
+				Severity: SeverityError,
+				Message:  "error",
+			},
+		},
+		{
+			// U+3042 is 1 code unit and U+1F600 is 2 code units in UTF-16
+			"non-ascii",
+			locerr.WarningIn(src.PosAt(21), src.PosAt(22), "warning").WithCode("W001"),
+			Diagnostic{
+				Range:    Range{Position{1, 7}, Position{1, 8}},
+				Severity: SeverityWarning,
+				Code:     "W001",
+				Message:  "warning",
+			},
+		},
+		{
+			"range across lines",
+			locerr.InfoIn(src.PosAt(8), src.PosAt(14), "info"),
+			Diagnostic{
+				Range:    Range{Position{0, 8}, Position{1, 4}},
+				Severity: SeverityInformation,
+				Message:  "info",
+			},
+		},
+		{
+			"position",
+			locerr.HintAt(src.PosAt(14), "hint"),
+			Diagnostic{
+				Range:    Range{Position{1, 4}, Position{1, 4}},
+				Severity: SeverityHint,
+				Message:  "hint",
+			},
+		},
+		{
+			"notes",
+			locerr.ErrorAt(src.PosAt(4), "error").
+				NoteIn(other.PosAt(4), other.PosAt(7), "defined here").
+				Help("fix it"),
+			Diagnostic{
+				Range:    Range{Position{0, 4}, Position{0, 4}},
+				Severity: SeverityError,
+				Message:  "error\nHelp: fix it",
+				RelatedInformation: []DiagnosticRelatedInformation{
+					{
+						Location: Location{"file:///path/to/other.txt", Range{Position{1, 0}, Position{1, 3}}},
+						Message:  "defined here",
+					},
+				},
+			},
+		},
+		{
+			"without position",
+			locerr.NewError("error"),
+			Diagnostic{Severity: SeverityError, Message: "error"},
+		},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			have := NewDiagnostic(tc.err)
+			if !reflect.DeepEqual(have, tc.want) {
+				t.Fatalf("Unexpected diagnostic.\nwant: %+v\nhave: %+v", tc.want, have)
+			}
+		})
+	}
+}
+
+func TestNewDiagnosticsJSON(t *testing.T) {
+	b, err := json.Marshal(NewDiagnostics(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "[]" {
+		t.Fatal("Empty diagnostics should be encoded to empty array:", string(b))
+	}
+
+	src := &locerr.Source{Path: "/test.txt", Code: []byte("abc")}
+	b, err = json.Marshal(NewDiagnostics([]*locerr.Error{locerr.ErrorAt(src.PosAt(1), "error").WithCode("E001")}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"range":{"start":{"line":0,"character":1},"end":{"line":0,"character":1}},"severity":1,"code":"E001","message":"error"}]`
+	if string(b) != want {
+		t.Fatalf("Unexpected JSON.\nwant: %s\nhave: %s", want, b)
+	}
+}
@@ -0,0 +1,62 @@
+/*
+Package lsp provides conversion from locerr.Error to diagnostics of Language Server Protocol and a
+minimal language server which publishes them.
+
+Types in this package are plain Go structs which are encoded to JSON as defined in the
+specification. Only the subset needed to publish diagnostics is defined.
+
+https://microsoft.github.io/language-server-protocol/specification
+*/
+package lsp
+
+// DocumentURI is a URI of a document such as "file:///path/to/file".
+type DocumentURI string
+
+// Position is a zero-based position in a document. Character is an offset in the line counted in
+// UTF-16 code units.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a range in a document. End is exclusive.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location is a range in a document.
+type Location struct {
+	URI   DocumentURI `json:"uri"`
+	Range Range       `json:"range"`
+}
+
+// DiagnosticSeverity is a severity of diagnostic.
+type DiagnosticSeverity int
+
+const (
+	// SeverityError reports an error.
+	SeverityError DiagnosticSeverity = 1
+	// SeverityWarning reports a warning.
+	SeverityWarning DiagnosticSeverity = 2
+	// SeverityInformation reports an information.
+	SeverityInformation DiagnosticSeverity = 3
+	// SeverityHint reports a hint.
+	SeverityHint DiagnosticSeverity = 4
+)
+
+// DiagnosticRelatedInformation is a message related to a diagnostic at other location.
+type DiagnosticRelatedInformation struct {
+	Location Location `json:"location"`
+	Message  string   `json:"message"`
+}
+
+// Diagnostic is an error or a warning shown in editor.
+type Diagnostic struct {
+	Range              Range                          `json:"range"`
+	Severity           DiagnosticSeverity             `json:"severity,omitempty"`
+	Code               string                         `json:"code,omitempty"`
+	Source             string                         `json:"source,omitempty"`
+	Message            string                         `json:"message"`
+	RelatedInformation []DiagnosticRelatedInformation `json:"relatedInformation,omitempty"`
+}
@@ -0,0 +1,248 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/rhysd/locerr"
+)
+
+// CheckFunc checks the source and returns errors found in it.
+type CheckFunc func(src *locerr.Source) []*locerr.Error
+
+// textDocumentSyncFull means that documents are synced by always sending the full content.
+const textDocumentSyncFull = 1
+
+type textDocumentItem struct {
+	URI     DocumentURI `json:"uri"`
+	Version int         `json:"version"`
+	Text    string      `json:"text"`
+}
+
+type textDocumentIdentifier struct {
+	URI     DocumentURI `json:"uri"`
+	Version int         `json:"version"`
+}
+
+type didOpenParams struct {
+	TextDocument textDocumentItem `json:"textDocument"`
+}
+
+type didChangeParams struct {
+	TextDocument   textDocumentIdentifier `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+}
+
+type didCloseParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+}
+
+type publishDiagnosticsParams struct {
+	URI         DocumentURI  `json:"uri"`
+	Version     *int         `json:"version,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+type initializeResult struct {
+	Capabilities struct {
+		TextDocumentSync int `json:"textDocumentSync"`
+	} `json:"capabilities"`
+	ServerInfo struct {
+		Name string `json:"name"`
+	} `json:"serverInfo"`
+}
+
+// Server is a minimal language server which publishes diagnostics. It keeps the content of each
+// opened document in memory and checks it with the callback whenever the document is opened or
+// changed. Errors returned from the callback are published as diagnostics of the document.
+//
+// It handles 'initialize', 'shutdown' requests and 'initialized', 'textDocument/didOpen',
+// 'textDocument/didChange', 'textDocument/didClose' and 'exit' notifications. Other requests are
+// responded with MethodNotFound error and other notifications are ignored.
+type Server struct {
+	name        string
+	check       CheckFunc
+	out         io.Writer
+	mu          sync.Mutex
+	docs        map[DocumentURI]*locerr.Source
+	initialized bool
+	shutdown    bool
+}
+
+// NewServer makes a new server. The name is used as server name and as source of diagnostics.
+func NewServer(name string, check CheckFunc) *Server {
+	return &Server{
+		name:  name,
+		check: check,
+		docs:  map[DocumentURI]*locerr.Source{},
+	}
+}
+
+// Document returns the current content of the opened document. It returns nil when the document
+// is not opened. It is safe to call this method while the server is running.
+func (s *Server) Document(uri DocumentURI) *locerr.Source {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.docs[uri]
+}
+
+// ServeStdio runs the server on stdin and stdout. See Serve for details.
+func (s *Server) ServeStdio() error {
+	return s.Serve(os.Stdin, os.Stdout)
+}
+
+// Serve reads JSON-RPC messages from r and writes messages to w until 'exit' notification is
+// received. It returns nil when 'exit' notification was received after 'shutdown' request.
+// Otherwise it returns an error such as io.EOF.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.out = w
+	br := bufio.NewReader(r)
+	for {
+		b, err := readMessage(br)
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err := json.Unmarshal(b, &req); err != nil {
+			if err := s.respondError(json.RawMessage("null"), codeParseError, err.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return fmt.Errorf("'exit' notification was received before 'shutdown' request")
+			}
+			return nil
+		}
+		if err := s.handle(&req); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(req *request) error {
+	if req.ID == nil {
+		return s.handleNotification(req)
+	}
+
+	if req.Method == "" {
+		return s.respondError(req.ID, codeInvalidRequest, "method is missing")
+	}
+	if req.Method != "initialize" && !s.initialized {
+		return s.respondError(req.ID, codeServerNotInitialized, "server is not initialized yet")
+	}
+
+	switch req.Method {
+	case "initialize":
+		var res initializeResult
+		res.Capabilities.TextDocumentSync = textDocumentSyncFull
+		res.ServerInfo.Name = s.name
+		s.initialized = true
+		return s.respond(req.ID, &res)
+	case "shutdown":
+		s.shutdown = true
+		return s.respond(req.ID, nil)
+	default:
+		return s.respondError(req.ID, codeMethodNotFound, fmt.Sprintf("method %q is not supported", req.Method))
+	}
+}
+
+func (s *Server) handleNotification(req *request) error {
+	if !s.initialized {
+		return nil // Notifications before initialization are dropped
+	}
+
+	switch req.Method {
+	case "textDocument/didOpen":
+		var p didOpenParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil // Notification cannot be responded even if it is invalid
+		}
+		return s.update(p.TextDocument.URI, p.TextDocument.Version, p.TextDocument.Text)
+	case "textDocument/didChange":
+		var p didChangeParams
+		if err := json.Unmarshal(req.Params, &p); err != nil || len(p.ContentChanges) == 0 {
+			return nil
+		}
+		// Since documents are synced with full content, the last change is the current content
+		return s.update(p.TextDocument.URI, p.TextDocument.Version, p.ContentChanges[len(p.ContentChanges)-1].Text)
+	case "textDocument/didClose":
+		var p didCloseParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil
+		}
+		s.mu.Lock()
+		delete(s.docs, p.TextDocument.URI)
+		s.mu.Unlock()
+		// Clear diagnostics of the closed document
+		return s.notify("textDocument/publishDiagnostics", &publishDiagnosticsParams{
+			URI:         p.TextDocument.URI,
+			Diagnostics: []Diagnostic{},
+		})
+	default:
+		return nil
+	}
+}
+
+// update replaces the content of the document and publishes diagnostics for it.
+func (s *Server) update(uri DocumentURI, version int, text string) error {
+	src := &locerr.Source{Path: PathFromURI(uri), Code: []byte(text)}
+	s.mu.Lock()
+	s.docs[uri] = src
+	s.mu.Unlock()
+
+	errs := []*locerr.Error{}
+	for _, err := range s.check(src) {
+		// Errors without position are shown at the head of the document
+		if f := err.Start.File; f == nil || f == src || f.Path == src.Path {
+			errs = append(errs, err)
+		}
+	}
+
+	diags := newDiagnostics(errs, s.uriOf)
+	for i := range diags {
+		diags[i].Source = s.name
+	}
+	return s.notify("textDocument/publishDiagnostics", &publishDiagnosticsParams{
+		URI:         uri,
+		Version:     &version,
+		Diagnostics: diags,
+	})
+}
+
+// uriOf returns the URI of the source. Opened documents are mapped to the URIs sent by the client
+// since a URI such as 'untitled:Untitled-1' cannot be restored from the path of the source.
+func (s *Server) uriOf(src *locerr.Source) DocumentURI {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for uri, doc := range s.docs {
+		if doc == src {
+			return uri
+		}
+	}
+	return URIFromPath(src.Path)
+}
+
+func (s *Server) respond(id json.RawMessage, result interface{}) error {
+	b, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return writeMessage(s.out, &response{JSONRPC: "2.0", ID: id, Result: b})
+}
+
+func (s *Server) respondError(id json.RawMessage, code int, msg string) error {
+	return writeMessage(s.out, &response{JSONRPC: "2.0", ID: id, Error: &responseError{code, msg}})
+}
+
+func (s *Server) notify(method string, params interface{}) error {
+	return writeMessage(s.out, &notification{JSONRPC: "2.0", Method: method, Params: params})
+}
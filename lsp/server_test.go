@@ -0,0 +1,271 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rhysd/locerr"
+)
+
+// testClient talks to a server running in another goroutine via in-process pipes.
+type testClient struct {
+	t    *testing.T
+	in   *io.PipeWriter
+	out  *bufio.Reader
+	done chan error
+}
+
+func newTestClient(t *testing.T, s *Server) *testClient {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	c := &testClient{t, inW, bufio.NewReader(outR), make(chan error, 1)}
+	go func() {
+		err := s.Serve(inR, outW)
+		outW.Close()
+		c.done <- err
+	}()
+	return c
+}
+
+func (c *testClient) send(msg string) {
+	// Do not use writeMessage since msg may be broken JSON
+	if _, err := fmt.Fprintf(c.in, "Content-Length: %d\r\n\r\n%s", len(msg), msg); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *testClient) recv() map[string]interface{} {
+	b, err := readMessage(c.out)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		c.t.Fatal(err)
+	}
+	return m
+}
+
+// recvJSON receives a message and compares it with the expected JSON.
+func (c *testClient) recvJSON(want string) {
+	c.t.Helper()
+	have := c.recv()
+	var w map[string]interface{}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		c.t.Fatal(err)
+	}
+	if !reflect.DeepEqual(have, w) {
+		b, _ := json.Marshal(have)
+		c.t.Fatalf("Unexpected message.\nwant: %s\nhave: %s", want, b)
+	}
+}
+
+func (c *testClient) initialize() {
+	c.send(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"capabilities":{}}}`)
+	c.recvJSON(`{"jsonrpc":"2.0","id":1,"result":{"capabilities":{"textDocumentSync":1},"serverInfo":{"name":"test"}}}`)
+	c.send(`{"jsonrpc":"2.0","method":"initialized","params":{}}`)
+}
+
+func (c *testClient) exit() error {
+	c.send(`{"jsonrpc":"2.0","id":"last","method":"shutdown"}`)
+	c.recvJSON(`{"jsonrpc":"2.0","id":"last","result":null}`)
+	c.send(`{"jsonrpc":"2.0","method":"exit"}`)
+	return <-c.done
+}
+
+// checkTODO reports 'TODO' in the source as warnings.
+func checkTODO(src *locerr.Source) []*locerr.Error {
+	errs := []*locerr.Error{}
+	code := string(src.Code)
+	for o, i := 0, 0; ; o += i + 4 {
+		i = strings.Index(code[o:], "TODO")
+		if i < 0 {
+			break
+		}
+		errs = append(errs, locerr.WarningIn(src.PosAt(o+i), src.PosAt(o+i+4), "TODO remains").WithCode("W001"))
+	}
+	return errs
+}
+
+func TestServerPublishDiagnostics(t *testing.T) {
+	s := NewServer("test", checkTODO)
+	c := newTestClient(t, s)
+	c.initialize()
+
+	c.send(`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///test.txt","languageId":"text","version":1,"text":"// あ TODO\nfoo"}}}`)
+	c.recvJSON(`{"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"uri":"file:///test.txt","version":1,"diagnostics":[
+		{"range":{"start":{"line":0,"character":5},"end":{"line":0,"character":9}},"severity":2,"code":"W001","source":"test","message":"TODO remains"}
+	]}}`)
+
+	src := s.Document("file:///test.txt")
+	if src == nil || string(src.Code) != "// あ TODO\nfoo" {
+		t.Fatal("Unexpected document:", src)
+	}
+
+	c.send(`{"jsonrpc":"2.0","method":"textDocument/didChange","params":{"textDocument":{"uri":"file:///test.txt","version":2},"contentChanges":[{"text":"foo\nbar TODO TODO"}]}}`)
+	c.recvJSON(`{"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"uri":"file:///test.txt","version":2,"diagnostics":[
+		{"range":{"start":{"line":1,"character":4},"end":{"line":1,"character":8}},"severity":2,"code":"W001","source":"test","message":"TODO remains"},
+		{"range":{"start":{"line":1,"character":9},"end":{"line":1,"character":13}},"severity":2,"code":"W001","source":"test","message":"TODO remains"}
+	]}}`)
+
+	c.send(`{"jsonrpc":"2.0","method":"textDocument/didChange","params":{"textDocument":{"uri":"file:///test.txt","version":3},"contentChanges":[{"text":"fixed"}]}}`)
+	c.recvJSON(`{"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"uri":"file:///test.txt","version":3,"diagnostics":[]}}`)
+	if src := s.Document("file:///test.txt"); src == nil || string(src.Code) != "fixed" {
+		t.Fatal("Document was not updated:", src)
+	}
+
+	c.send(`{"jsonrpc":"2.0","method":"textDocument/didClose","params":{"textDocument":{"uri":"file:///test.txt"}}}`)
+	c.recvJSON(`{"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"uri":"file:///test.txt","diagnostics":[]}}`)
+	if s.Document("file:///test.txt") != nil {
+		t.Fatal("Closed document should be removed")
+	}
+
+	if err := c.exit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServerFiltersErrorsInOtherFiles(t *testing.T) {
+	other := &locerr.Source{Path: "/other.txt", Code: []byte("abc")}
+	s := NewServer("test", func(src *locerr.Source) []*locerr.Error {
+		return []*locerr.Error{
+			locerr.ErrorAt(other.PosAt(0), "in other file"),
+			locerr.NewError("without position"),
+			locerr.ErrorAt(src.PosAt(1), "in this file").NoteAt(other.PosAt(1), "note"),
+		}
+	})
+	c := newTestClient(t, s)
+	c.initialize()
+
+	c.send(`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///test.txt","languageId":"text","version":1,"text":"abc"}}}`)
+	c.recvJSON(`{"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"uri":"file:///test.txt","version":1,"diagnostics":[
+		{"range":{"start":{"line":0,"character":0},"end":{"line":0,"character":0}},"severity":1,"source":"test","message":"without position"},
+		{"range":{"start":{"line":0,"character":1},"end":{"line":0,"character":1}},"severity":1,"source":"test","message":"in this file","relatedInformation":[
+			{"location":{"uri":"file:///other.txt","range":{"start":{"line":0,"character":1},"end":{"line":0,"character":1}}},"message":"note"}
+		]}
+	]}}`)
+
+	if err := c.exit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServerUntitledDocument(t *testing.T) {
+	s := NewServer("test", func(src *locerr.Source) []*locerr.Error {
+		return []*locerr.Error{
+			locerr.ErrorAt(src.PosAt(4), "redefined").NoteAt(src.PosAt(0), "defined here"),
+		}
+	})
+	c := newTestClient(t, s)
+	c.initialize()
+
+	// The URI of related information must be the URI of the document, not a file:// URI
+	c.send(`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"untitled:Untitled-1","languageId":"text","version":1,"text":"abc\nabc"}}}`)
+	c.recvJSON(`{"jsonrpc":"2.0","method":"textDocument/publishDiagnostics","params":{"uri":"untitled:Untitled-1","version":1,"diagnostics":[
+		{"range":{"start":{"line":1,"character":0},"end":{"line":1,"character":0}},"severity":1,"source":"test","message":"redefined","relatedInformation":[
+			{"location":{"uri":"untitled:Untitled-1","range":{"start":{"line":0,"character":0},"end":{"line":0,"character":0}}},"message":"defined here"}
+		]}
+	]}}`)
+
+	if err := c.exit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	s := NewServer("test", checkTODO)
+	c := newTestClient(t, s)
+
+	// Notifications and requests before initialization
+	c.send(`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"file:///test.txt","version":1,"text":"TODO"}}}`)
+	c.send(`{"jsonrpc":"2.0","id":1,"method":"shutdown"}`)
+	c.recvJSON(`{"jsonrpc":"2.0","id":1,"error":{"code":-32002,"message":"server is not initialized yet"}}`)
+
+	c.initialize()
+
+	c.send(`{"jsonrpc":"2.0","id":2,"method":"textDocument/hover","params":{}}`)
+	c.recvJSON(`{"jsonrpc":"2.0","id":2,"error":{"code":-32601,"message":"method \"textDocument/hover\" is not supported"}}`)
+
+	c.send(`{"jsonrpc":"2.0","id":3,`)
+	m := c.recv()
+	if e, ok := m["error"].(map[string]interface{}); !ok || e["code"] != float64(-32700) || m["id"] != nil {
+		t.Fatal("Parse error should be responded:", m)
+	}
+
+	// Unknown notification is ignored
+	c.send(`{"jsonrpc":"2.0","method":"$/cancelRequest","params":{"id":2}}`)
+
+	if err := c.exit(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServerExitWithoutShutdown(t *testing.T) {
+	c := newTestClient(t, NewServer("test", checkTODO))
+	c.initialize()
+	c.send(`{"jsonrpc":"2.0","method":"exit"}`)
+	if err := <-c.done; err == nil {
+		t.Fatal("Exit without shutdown should cause an error")
+	}
+}
+
+func TestServerConnectionClosed(t *testing.T) {
+	c := newTestClient(t, NewServer("test", checkTODO))
+	c.initialize()
+	c.in.Close()
+	if err := <-c.done; err != io.EOF {
+		t.Fatal("io.EOF should be returned when connection is closed but", err)
+	}
+}
+
+func TestReadMessage(t *testing.T) {
+	for _, tc := range []struct {
+		what  string
+		input string
+		want  string
+	}{
+		{"with content type", "Content-Type: application/vscode-jsonrpc; charset=utf-8\r\nContent-Length: 2\r\n\r\n{}", "{}"},
+		{"case insensitive header", "content-length: 2\r\n\r\n{}", "{}"},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			b, err := readMessage(bufio.NewReader(strings.NewReader(tc.input)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tc.want {
+				t.Fatalf("Wanted %q but have %q", tc.want, b)
+			}
+		})
+	}
+
+	for _, tc := range []struct {
+		what  string
+		input string
+	}{
+		{"no content length", "Content-Type: foo\r\n\r\n{}"},
+		{"invalid content length", "Content-Length: foo\r\n\r\n{}"},
+		{"invalid header", "foo\r\n\r\n{}"},
+		{"short body", "Content-Length: 10\r\n\r\n{}"},
+		{"unexpected EOF in header", "Content-Length: 2"},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			if _, err := readMessage(bufio.NewReader(strings.NewReader(tc.input))); err == nil {
+				t.Fatal("Error should occur")
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := writeMessage(&buf, map[string]int{"a": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Content-Length: 7\r\n\r\n{\"a\":1}"; buf.String() != want {
+		t.Fatalf("Wanted %q but have %q", want, buf.String())
+	}
+}
@@ -18,13 +18,14 @@ type Pos struct {
 	Offset int
 	// Line number.
 	Line int
-	// Column number.
+	// Column number. It is assumed to be counted in bytes. See SetColumnUnit for other units.
 	Column int
 	// File of this position.
 	File *Source
 }
 
-// String makes a string representation of the position. Format is 'file:line:column'.
+// String makes a string representation of the position. Format is 'file:line:column'. Unit of the
+// column can be changed by SetColumnUnit.
 func (p Pos) String() string {
 	if p.File == nil {
 		return "<unknown>:0:0"
@@ -33,5 +34,89 @@ func (p Pos) String() string {
 	if p.File.Exists && currentDir != "" && filepath.HasPrefix(f, currentDir) {
 		f, _ = filepath.Rel(currentDir, f)
 	}
-	return fmt.Sprintf("%s:%d:%d", f, p.Line, p.Column)
+	return fmt.Sprintf("%s:%d:%d", f, p.Line, p.ColumnIn(columnUnit))
+}
+
+// ColumnIn returns the column of the position counted in the unit. Column is calculated from the
+// offset. When the offset does not agree with the line (e.g. offset is unknown), Column field is
+// returned as-is.
+func (p Pos) ColumnIn(unit ColumnUnit) int {
+	if unit == ColumnBytes || p.File == nil || p.Offset < 0 || len(p.File.Code) < p.Offset {
+		return p.Column
+	}
+	if p.File.PosAt(p.Offset).Line != p.Line {
+		return p.Column
+	}
+	return p.File.Column(p.Offset, unit)
+}
+
+// IsValid returns whether the position points to some place in its source.
+func (p Pos) IsValid() bool {
+	return p.File != nil && p.Line >= 1 && p.Column >= 1 && 0 <= p.Offset && p.Offset <= len(p.File.Code)
+}
+
+// Validate checks that the position is valid and its line and column agree with its offset in the
+// source. Column is assumed to be in bytes. It returns an error describing the inconsistency.
+func (p Pos) Validate() error {
+	if p.File == nil {
+		return fmt.Errorf("position has no source: %s", p)
+	}
+	if p.Offset < 0 || len(p.File.Code) < p.Offset {
+		return fmt.Errorf("offset %d is out of source %s (length %d)", p.Offset, p.File.Path, len(p.File.Code))
+	}
+	if !p.IsValid() {
+		return fmt.Errorf("line and column must start from 1: %s", p)
+	}
+	want := p.File.PosAt(p.Offset)
+	if want.Line != p.Line || want.Column != p.Column {
+		return fmt.Errorf("line and column of position %s do not agree with its offset %d (should be %s)", p, p.Offset, want)
+	}
+	return nil
+}
+
+func (p Pos) path() string {
+	if p.File == nil {
+		return ""
+	}
+	return p.File.Path
+}
+
+// Compare compares two positions. It returns a negative integer when p is before q, a positive
+// integer when p is after q, or 0 when they are equal. Positions are ordered by their file paths
+// and then their offsets. Positions without file are ordered before positions with file. When
+// their offsets are the same, lines and columns are compared (e.g. positions whose offsets are
+// unknown).
+func (p Pos) Compare(q Pos) int {
+	if p.File == nil || q.File == nil {
+		if p.File != nil {
+			return 1
+		}
+		if q.File != nil {
+			return -1
+		}
+	} else if f, g := p.path(), q.path(); f != g {
+		if f < g {
+			return -1
+		}
+		return 1
+	}
+	for _, d := range []int{p.Offset - q.Offset, p.Line - q.Line, p.Column - q.Column} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Before returns whether p is before q. See Compare for the order.
+func (p Pos) Before(q Pos) bool {
+	return p.Compare(q) < 0
+}
+
+// Equal returns whether p and q point the same place. Sources are compared by their paths.
+func (p Pos) Equal(q Pos) bool {
+	return p.Compare(q) == 0
 }
@@ -41,3 +41,81 @@ func TestPosStringCanonicalPath(t *testing.T) {
 		t.Fatal(want, "was wanted but have", have)
 	}
 }
+
+func TestPosIsValidAndValidate(t *testing.T) {
+	src := NewDummySource("abc\ndef")
+	for _, tc := range []struct {
+		what  string
+		pos   Pos
+		valid bool
+		err   string
+	}{
+		{"valid", Pos{5, 2, 2, src}, true, ""},
+		{"end of source", Pos{7, 2, 4, src}, true, ""},
+		{"no source", Pos{}, false, "position has no source: <unknown>:0:0"},
+		{"negative offset", Pos{-1, 1, 1, src}, false, "offset -1 is out of source <dummy> (length 7)"},
+		{"offset exceeds source", Pos{8, 2, 5, src}, false, "offset 8 is out of source <dummy> (length 7)"},
+		{"zero line", Pos{0, 0, 1, src}, false, "line and column must start from 1: <dummy>:0:1"},
+		{"zero column", Pos{0, 1, 0, src}, false, "line and column must start from 1: <dummy>:1:0"},
+		{"wrong line", Pos{5, 1, 2, src}, true, "line and column of position <dummy>:1:2 do not agree with its offset 5 (should be <dummy>:2:2)"},
+		{"wrong column", Pos{5, 2, 1, src}, true, "line and column of position <dummy>:2:1 do not agree with its offset 5 (should be <dummy>:2:2)"},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			if tc.pos.IsValid() != tc.valid {
+				t.Errorf("IsValid() should be %v", tc.valid)
+			}
+			err := tc.pos.Validate()
+			if tc.err == "" {
+				if err != nil {
+					t.Fatal("Unexpected error:", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Error should occur")
+			}
+			if err.Error() != tc.err {
+				t.Fatalf("Unexpected error message.\nwant: '%s'\nhave: '%s'", tc.err, err.Error())
+			}
+		})
+	}
+}
+
+func TestPosCompare(t *testing.T) {
+	a := NewDummySource("abc\ndef")
+	a.Path = "a"
+	a2 := NewDummySource("abc\ndef")
+	a2.Path = "a"
+	b := NewDummySource("abc\ndef")
+	b.Path = "b"
+
+	for _, tc := range []struct {
+		what string
+		p    Pos
+		q    Pos
+		want int
+	}{
+		{"same", a.PosAt(3), a.PosAt(3), 0},
+		{"offset", a.PosAt(2), a.PosAt(3), -1},
+		{"offset reversed", a.PosAt(3), a.PosAt(2), 1},
+		{"same path", a.PosAt(3), a2.PosAt(3), 0},
+		{"file path", b.PosAt(0), a.PosAt(5), 1},
+		{"file path reversed", a.PosAt(5), b.PosAt(0), -1},
+		{"nil file", Pos{}, a.PosAt(0), -1},
+		{"nil file reversed", a.PosAt(0), Pos{}, 1},
+		{"both nil file", Pos{0, 1, 2, nil}, Pos{0, 1, 3, nil}, -1},
+		{"unknown offset", Pos{0, 3, 1, a}, Pos{0, 2, 5, a}, 1},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			if have := tc.p.Compare(tc.q); have != tc.want {
+				t.Fatalf("Compare(%s, %s) should be %d but %d", tc.p, tc.q, tc.want, have)
+			}
+			if have := tc.p.Before(tc.q); have != (tc.want < 0) {
+				t.Fatalf("Before(%s, %s) should be %v", tc.p, tc.q, tc.want < 0)
+			}
+			if have := tc.p.Equal(tc.q); have != (tc.want == 0) {
+				t.Fatalf("Equal(%s, %s) should be %v", tc.p, tc.q, tc.want == 0)
+			}
+		})
+	}
+}
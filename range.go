@@ -0,0 +1,96 @@
+package locerr
+
+// Range represents a range in a source code. Start is inclusive and End is exclusive. When
+// End.File is nil, the range has no end and it only indicates Start position.
+type Range struct {
+	// Start position of the range.
+	Start Pos
+	// End position of the range.
+	End Pos
+}
+
+func sameFile(a, b *Source) bool {
+	return a == b || a != nil && b != nil && a.Path == b.Path
+}
+
+// IsEmpty returns whether the range contains no code.
+func (r Range) IsEmpty() bool {
+	return r.Start.File == nil || r.End.File == nil || r.End.Offset <= r.Start.Offset
+}
+
+// Len returns the length of the range in bytes.
+func (r Range) Len() int {
+	if r.IsEmpty() {
+		return 0
+	}
+	return r.End.Offset - r.Start.Offset
+}
+
+// Contains returns whether the position is in the range. Empty range contains no position.
+func (r Range) Contains(p Pos) bool {
+	if r.IsEmpty() || !sameFile(r.Start.File, p.File) {
+		return false
+	}
+	return r.Start.Offset <= p.Offset && p.Offset < r.End.Offset
+}
+
+// Overlaps returns whether the two ranges share some code. Empty ranges overlap with nothing.
+func (r Range) Overlaps(o Range) bool {
+	if r.IsEmpty() || o.IsEmpty() || !sameFile(r.Start.File, o.Start.File) {
+		return false
+	}
+	return r.Start.Offset < o.End.Offset && o.Start.Offset < r.End.Offset
+}
+
+// Union returns the smallest range which contains both ranges. Range without end is treated as an
+// empty range at its start. When the ranges are in different files, r is returned as-is.
+func (r Range) Union(o Range) Range {
+	if r.Start.File == nil {
+		return o
+	}
+	if o.Start.File == nil || !sameFile(r.Start.File, o.Start.File) {
+		return r
+	}
+	end := func(r Range) Pos {
+		if r.End.File == nil {
+			return r.Start
+		}
+		return r.End
+	}
+	u := Range{r.Start, end(r)}
+	if o.Start.Offset < u.Start.Offset {
+		u.Start = o.Start
+	}
+	if e := end(o); e.Offset > u.End.Offset {
+		u.End = e
+	}
+	return u
+}
+
+// Text returns the code in the range. It returns nil when the range is empty or out of the source.
+func (r Range) Text() []byte {
+	if r.IsEmpty() || r.Start.Offset < 0 || len(r.Start.File.Code) < r.End.Offset {
+		return nil
+	}
+	return r.Start.File.Code[r.Start.Offset:r.End.Offset]
+}
+
+// Range returns the range of the error.
+func (err *Error) Range() Range {
+	return Range{err.Start, err.End}
+}
+
+// InRange sets the range of the error.
+func (err *Error) InRange(r Range) *Error {
+	return err.In(r.Start, r.End)
+}
+
+// ErrorInRange makes a new compilation error with the range.
+func ErrorInRange(r Range, msg string) *Error {
+	return ErrorIn(r.Start, r.End, msg)
+}
+
+// ErrorfInRange makes a new compilation error with the range and formatted message.
+func ErrorfInRange(r Range, format string, args ...interface{}) *Error {
+	return ErrorfIn(r.Start, r.End, format, args...)
+}
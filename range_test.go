@@ -0,0 +1,145 @@
+package locerr
+
+import (
+	"testing"
+)
+
+func TestRangeLenAndText(t *testing.T) {
+	src := NewDummySource("abc\ndef")
+	for _, tc := range []struct {
+		what  string
+		r     Range
+		len   int
+		text  string
+		empty bool
+	}{
+		{"whole", Range{src.PosAt(0), src.PosAt(7)}, 7, "abc\ndef", false},
+		{"part", Range{src.PosAt(2), src.PosAt(5)}, 3, "c\nd", false},
+		{"no end", Range{src.PosAt(2), Pos{}}, 0, "", true},
+		{"same position", Range{src.PosAt(2), src.PosAt(2)}, 0, "", true},
+		{"reversed", Range{src.PosAt(4), src.PosAt(2)}, 0, "", true},
+		{"zero", Range{}, 0, "", true},
+		{"negative start offset", Range{Pos{-1, 1, 0, src}, src.PosAt(2)}, 3, "", false},
+		{"end offset out of source", Range{src.PosAt(2), Pos{10, 2, 7, src}}, 8, "", false},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			if tc.r.Len() != tc.len {
+				t.Errorf("Length should be %d but %d", tc.len, tc.r.Len())
+			}
+			if string(tc.r.Text()) != tc.text {
+				t.Errorf("Text should be %q but %q", tc.text, tc.r.Text())
+			}
+			if tc.r.IsEmpty() != tc.empty {
+				t.Errorf("IsEmpty() should be %v", tc.empty)
+			}
+		})
+	}
+}
+
+func TestRangeContains(t *testing.T) {
+	src := NewDummySource("abcdef")
+	other := NewDummySource("abcdef")
+	other.Path = "other"
+	r := Range{src.PosAt(1), src.PosAt(4)}
+
+	for _, tc := range []struct {
+		pos  Pos
+		want bool
+	}{
+		{src.PosAt(0), false},
+		{src.PosAt(1), true},
+		{src.PosAt(3), true},
+		{src.PosAt(4), false},
+		{other.PosAt(2), false},
+		{Pos{}, false},
+	} {
+		if have := r.Contains(tc.pos); have != tc.want {
+			t.Errorf("Contains(%s) should be %v", tc.pos, tc.want)
+		}
+	}
+
+	if (Range{src.PosAt(1), Pos{}}).Contains(src.PosAt(1)) {
+		t.Error("Empty range should not contain any position")
+	}
+}
+
+func TestRangeOverlaps(t *testing.T) {
+	src := NewDummySource("abcdefghij")
+	other := NewDummySource("abcdefghij")
+	other.Path = "other"
+	r := Range{src.PosAt(2), src.PosAt(5)}
+
+	for _, tc := range []struct {
+		what string
+		r    Range
+		want bool
+	}{
+		{"same", r, true},
+		{"inner", Range{src.PosAt(3), src.PosAt(4)}, true},
+		{"outer", Range{src.PosAt(0), src.PosAt(9)}, true},
+		{"left", Range{src.PosAt(0), src.PosAt(3)}, true},
+		{"right", Range{src.PosAt(4), src.PosAt(7)}, true},
+		{"adjacent left", Range{src.PosAt(0), src.PosAt(2)}, false},
+		{"adjacent right", Range{src.PosAt(5), src.PosAt(7)}, false},
+		{"empty", Range{src.PosAt(3), src.PosAt(3)}, false},
+		{"other file", Range{other.PosAt(2), other.PosAt(5)}, false},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			if have := r.Overlaps(tc.r); have != tc.want {
+				t.Errorf("Overlaps() should be %v", tc.want)
+			}
+			if have := tc.r.Overlaps(r); have != tc.want {
+				t.Errorf("Overlaps() should be symmetric")
+			}
+		})
+	}
+}
+
+func TestRangeUnion(t *testing.T) {
+	src := NewDummySource("abcdefghij")
+	other := NewDummySource("abcdefghij")
+	other.Path = "other"
+	r := Range{src.PosAt(2), src.PosAt(5)}
+
+	for _, tc := range []struct {
+		what string
+		r    Range
+		want Range
+	}{
+		{"inner", Range{src.PosAt(3), src.PosAt(4)}, r},
+		{"disjoint", Range{src.PosAt(7), src.PosAt(9)}, Range{src.PosAt(2), src.PosAt(9)}},
+		{"left", Range{src.PosAt(0), src.PosAt(3)}, Range{src.PosAt(0), src.PosAt(5)}},
+		{"no end", Range{src.PosAt(8), Pos{}}, Range{src.PosAt(2), src.PosAt(8)}},
+		{"zero", Range{}, r},
+		{"other file", Range{other.PosAt(0), other.PosAt(9)}, r},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			if have := r.Union(tc.r); have != tc.want {
+				t.Errorf("Union should be %v but %v", tc.want, have)
+			}
+		})
+	}
+
+	if have := (Range{}).Union(r); have != r {
+		t.Errorf("Union with zero range should be %v but %v", r, have)
+	}
+}
+
+func TestErrorInRange(t *testing.T) {
+	src := NewDummySource("abc\ndef")
+	r := Range{src.PosAt(4), src.PosAt(7)}
+	want := "Error: text (at <dummy>:2:1)\n\n> def\n"
+
+	for _, err := range []*Error{
+		ErrorInRange(r, "text"),
+		ErrorfInRange(r, "%s", "text"),
+		NewError("text").InRange(r),
+	} {
+		if have := err.Error(); have != want {
+			t.Errorf("Unexpected error message.\nwant:\n'%s'\nhave:\n'%s'", want, have)
+		}
+		if err.Range() != r {
+			t.Errorf("Range should be %v but %v", r, err.Range())
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package locerr
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// CodeInfo is an entry of Registry which describes an error code.
+type CodeInfo struct {
+	// Code is an identifier of the error such as "E0042".
+	Code string
+	// Title is a short one-line summary of the error.
+	Title string
+	// Explanation is a long explanation of the error written in markdown. It usually describes why
+	// the error occurs and how to fix it with examples.
+	Explanation string
+	// URL is an optional link to the documentation of the error.
+	URL string
+}
+
+// Registry is a set of error codes registered by a project. It is used to show the long
+// explanation of an error code like `rustc --explain`. It is safe for concurrent use. The zero
+// value is an empty registry ready to use.
+type Registry struct {
+	mu    sync.RWMutex
+	codes map[string]CodeInfo
+}
+
+// NewRegistry makes a new empty registry.
+func NewRegistry() *Registry {
+	return &Registry{codes: map[string]CodeInfo{}}
+}
+
+// Register adds the entry to the registry. It returns an error when the code is empty or it was
+// already registered.
+func (r *Registry) Register(info CodeInfo) error {
+	if info.Code == "" {
+		return fmt.Errorf("error code must not be empty: %+v", info)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.codes[info.Code]; ok {
+		return fmt.Errorf("error code %s was already registered", info.Code)
+	}
+	if r.codes == nil {
+		r.codes = map[string]CodeInfo{}
+	}
+	r.codes[info.Code] = info
+	return nil
+}
+
+// Lookup returns the entry of the code. The second return value is false when the code is not
+// registered.
+func (r *Registry) Lookup(code string) (CodeInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	info, ok := r.codes[code]
+	return info, ok
+}
+
+// Codes returns all registered codes in sorted order.
+func (r *Registry) Codes() []string {
+	r.mu.RLock()
+	codes := make([]string, 0, len(r.codes))
+	for c := range r.codes {
+		codes = append(codes, c)
+	}
+	r.mu.RUnlock()
+	sort.Strings(codes)
+	return codes
+}
+
+// Explain writes the explanation of the code to the given writer. It returns an error when the
+// code is not registered.
+func (r *Registry) Explain(w io.Writer, code string) error {
+	info, ok := r.Lookup(code)
+	if !ok {
+		return fmt.Errorf("error code %s is not registered", code)
+	}
+
+	// {code}: {title}
+	//
+	// {explanation}
+	//
+	// See {url} for more details.
+	bold.Fprint(w, info.Code)
+	if info.Title != "" {
+		bold.Fprint(w, ": "+info.Title)
+	}
+	fmt.Fprint(w, "\n")
+	if e := strings.TrimSpace(info.Explanation); e != "" {
+		fmt.Fprintf(w, "\n%s\n", e)
+	}
+	if info.URL != "" {
+		fmt.Fprintf(w, "\nSee %s for more details.\n", info.URL)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package locerr
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestRegistryRegisterAndLookup(t *testing.T) {
+	r := NewRegistry()
+	info := CodeInfo{Code: "E0042", Title: "Type mismatch", Explanation: "Types do not match."}
+	if err := r.Register(info); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register(CodeInfo{Code: "E0001", Title: "Syntax error"}); err != nil {
+		t.Fatal(err)
+	}
+
+	have, ok := r.Lookup("E0042")
+	if !ok {
+		t.Fatal("Registered code was not found")
+	}
+	if have != info {
+		t.Fatalf("Unexpected entry. want %+v but have %+v", info, have)
+	}
+	if _, ok := r.Lookup("E9999"); ok {
+		t.Fatal("Unknown code should not be found")
+	}
+	if have, want := r.Codes(), []string{"E0001", "E0042"}; !reflect.DeepEqual(have, want) {
+		t.Fatalf("Unexpected codes. want %v but have %v", want, have)
+	}
+
+	if err := r.Register(CodeInfo{Code: "E0042"}); err == nil {
+		t.Fatal("Duplicate code should cause an error")
+	}
+	if err := r.Register(CodeInfo{Title: "No code"}); err == nil {
+		t.Fatal("Empty code should cause an error")
+	}
+}
+
+func TestRegistryZeroValue(t *testing.T) {
+	var r Registry
+	if _, ok := r.Lookup("E0001"); ok {
+		t.Fatal("Empty registry should not have any code")
+	}
+	if err := r.Register(CodeInfo{Code: "E0001"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.Lookup("E0001"); !ok {
+		t.Fatal("Registered code was not found")
+	}
+}
+
+func TestRegistryExplain(t *testing.T) {
+	r := NewRegistry()
+	r.Register(CodeInfo{
+		Code:        "E0042",
+		Title:       "Type mismatch",
+		Explanation: "\nTypes do not match.\n\n```\nlet x: int = true\n```\n",
+		URL:         "https://example.com/E0042",
+	})
+	r.Register(CodeInfo{Code: "E0001"})
+
+	for _, tc := range []struct {
+		code string
+		want string
+	}{
+		{
+			"E0042",
+			"E0042: Type mismatch\n\nTypes do not match.\n\n```\nlet x: int = true\n```\n\nSee https://example.com/E0042 for more details.\n",
+		},
+		{"E0001", "E0001\n"},
+	} {
+		t.Run(tc.code, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := r.Explain(&buf, tc.code); err != nil {
+				t.Fatal(err)
+			}
+			if have := buf.String(); have != tc.want {
+				t.Fatalf("Unexpected explanation.\nwant:\n'%s'\nhave:\n'%s'", tc.want, have)
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := r.Explain(&buf, "E9999"); err == nil {
+		t.Fatal("Unknown code should cause an error")
+	}
+	if buf.Len() != 0 {
+		t.Fatal("Nothing should be written for unknown code:", buf.String())
+	}
+}
@@ -0,0 +1,136 @@
+package locerr
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/mattn/go-colorable"
+)
+
+// Reporter is a sink of errors reported from multiple goroutines such as type checkers running in
+// parallel. It is safe for concurrent use. Reported errors are buffered per source file and
+// written in deterministic order on Flush so that the output does not depend on scheduling of
+// goroutines. The zero value is a reporter which writes errors to stderr.
+type Reporter struct {
+	mu      sync.Mutex
+	flushMu sync.Mutex // Serializes writes to out
+	out     io.Writer
+	files   map[string][]*Error
+	counts  map[Severity]int
+	written bool
+}
+
+// reportedMessage is an error rendered on flush.
+type reportedMessage struct {
+	err  *Error
+	text string
+}
+
+// NewReporter makes a new reporter which writes errors to the given writer. To support colors on
+// Windows, wrap the file with colorable.NewColorable.
+func NewReporter(out io.Writer) *Reporter {
+	return &Reporter{
+		out:    out,
+		files:  map[string][]*Error{},
+		counts: map[Severity]int{},
+	}
+}
+
+// Report buffers the error. It will be written on the next Flush call.
+func (r *Reporter) Report(err *Error) {
+	path := ""
+	if err.Start.File != nil {
+		path = err.Start.File.Path
+	}
+
+	r.mu.Lock()
+	if r.files == nil {
+		r.files = map[string][]*Error{}
+	}
+	if r.counts == nil {
+		r.counts = map[Severity]int{}
+	}
+	r.files[path] = append(r.files[path], err)
+	r.counts[err.Severity]++
+	r.mu.Unlock()
+}
+
+// Flush writes all buffered errors and clears the buffer. Errors are ordered by their file paths
+// and then their positions. Errors without position are written first. Errors are rendered
+// without blocking Report calls from other goroutines.
+func (r *Reporter) Flush() {
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+
+	r.mu.Lock()
+	files := r.files
+	r.files = map[string][]*Error{}
+	r.mu.Unlock()
+
+	out := r.out
+	if out == nil {
+		out = colorable.NewColorableStderr()
+	}
+
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var buf bytes.Buffer
+	for _, p := range paths {
+		errs := files[p]
+
+		// Render each error only once since the rendered messages are also used for sorting
+		msgs := make([]reportedMessage, 0, len(errs))
+		for _, err := range errs {
+			buf.Reset()
+			err.WriteMessage(&buf)
+			msgs = append(msgs, reportedMessage{err, string(bytes.TrimRight(buf.Bytes(), "\n"))})
+		}
+		sort.SliceStable(msgs, func(i, j int) bool {
+			if c := compareErrors(msgs[i].err, msgs[j].err); c != 0 {
+				return c < 0
+			}
+			// Errors reported at the same position with the same message from different goroutines
+			return msgs[i].text < msgs[j].text
+		})
+
+		if r.written {
+			fmt.Fprint(out, "\n")
+		}
+		for i, m := range msgs {
+			if i > 0 {
+				fmt.Fprint(out, "\n\n")
+			}
+			io.WriteString(out, m.text)
+		}
+		fmt.Fprint(out, "\n")
+		r.written = true
+	}
+}
+
+// Count returns the number of reported errors with the severity, including errors already flushed.
+func (r *Reporter) Count(s Severity) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.counts[s]
+}
+
+// HasErrors returns whether any error with SeverityError was reported. This is useful to decide
+// the exit status.
+func (r *Reporter) HasErrors() bool {
+	return r.Count(SeverityError) > 0
+}
+
+// Summary returns a summary line of the reported errors such as "2 errors, 1 warning". When
+// nothing was reported, it returns an empty string.
+func (r *Reporter) Summary() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return summary(r.counts, 0)
+}
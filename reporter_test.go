@@ -0,0 +1,113 @@
+package locerr
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestReporterFlushInDeterministicOrder(t *testing.T) {
+	srcs := make([]*Source, 4)
+	for i := range srcs {
+		srcs[i] = NewDummySource("aaa\nbbb\nccc")
+		srcs[i].Path = fmt.Sprintf("file%d", i)
+	}
+
+	var want string
+	for n := 0; n < 10; n++ {
+		var buf bytes.Buffer
+		r := NewReporter(&buf)
+
+		var wg sync.WaitGroup
+		for i := len(srcs) - 1; i >= 0; i-- {
+			wg.Add(1)
+			go func(src *Source) {
+				defer wg.Done()
+				r.Report(ErrorAt(src.PosAt(8), "error at line 3"))
+				r.Report(WarningAt(src.PosAt(0), "warning at line 1"))
+				r.Report(NewError("no position"))
+				r.Report(ErrorAt(src.PosAt(4), "error at line 2"))
+			}(srcs[i])
+		}
+		wg.Wait()
+		r.Flush()
+
+		if n == 0 {
+			want = buf.String()
+			continue
+		}
+		if have := buf.String(); have != want {
+			t.Fatalf("Output depends on scheduling.\nwant:\n'%s'\nhave:\n'%s'", want, have)
+		}
+	}
+
+	for i, w := range []string{
+		"Error: no position\n\nError: no position",
+		"Warning: warning at line 1 (at file0:1:1)\n\n> aaa\n\nError: error at line 2 (at file0:2:1)\n\n> bbb\n\nError: error at line 3 (at file0:3:1)\n\n> ccc\n\nWarning: warning at line 1 (at file1:1:1)",
+	} {
+		if !bytes.Contains([]byte(want), []byte(w)) {
+			t.Errorf("Output does not contain expected part %d:\n'%s'\nOutput:\n'%s'", i, w, want)
+		}
+	}
+}
+
+func TestReporterCountsAndMultipleFlushes(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewReporter(&buf)
+
+	if r.HasErrors() || r.Summary() != "" {
+		t.Fatal("Nothing should be reported yet")
+	}
+
+	r.Report(WarningAt(Pos{}, "warning"))
+	r.Flush()
+	if r.HasErrors() {
+		t.Fatal("Only warning was reported")
+	}
+
+	r.Report(NewError("error1"))
+	r.Report(NewError("error2"))
+	r.Flush()
+	r.Flush() // Nothing is written
+
+	want := "Warning: warning\n\nError: error1\n\nError: error2\n"
+	if have := buf.String(); have != want {
+		t.Fatalf("Unexpected output.\nwant:\n'%s'\nhave:\n'%s'", want, have)
+	}
+	if !r.HasErrors() || r.Count(SeverityError) != 2 || r.Count(SeverityWarning) != 1 || r.Count(SeverityHint) != 0 {
+		t.Fatal("Unexpected counts:", r.Count(SeverityError), r.Count(SeverityWarning), r.Count(SeverityHint))
+	}
+	if have := r.Summary(); have != "2 errors, 1 warning" {
+		t.Fatal("Unexpected summary:", have)
+	}
+}
+
+func TestReporterZeroValue(t *testing.T) {
+	f, err := ioutil.TempFile("", "locerr-reporter-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	defer func(saved *os.File) { os.Stderr = saved }(os.Stderr)
+	os.Stderr = f
+
+	var r Reporter
+	r.Flush() // Flush before any report
+	r.Report(NewError("error"))
+	r.Flush()
+	if r.Count(SeverityError) != 1 {
+		t.Fatal("Unexpected count:", r.Count(SeverityError))
+	}
+
+	b, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Error: error\n"; string(b) != want {
+		t.Fatalf("Unexpected output.\nwant:\n'%s'\nhave:\n'%s'", want, b)
+	}
+}
@@ -0,0 +1,252 @@
+package locerr
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const sarifSchemaURI = "https://docs.oasis-open.org/sarif/sarif/v2.1.0/errata01/os/schemas/sarif-schema-2.1.0.json"
+
+// SARIFTool describes the tool which reported errors in SARIF log.
+type SARIFTool struct {
+	// Name of the tool. This is required by SARIF.
+	Name string
+	// Version of the tool. This is optional.
+	Version string
+	// InformationURI is a URL of the tool's documentation. This is optional.
+	InformationURI string
+	// Registry describes error codes as rules of the tool. Codes which are used by the errors and
+	// registered in it are included in the log. This is optional.
+	Registry *Registry
+}
+
+type sarifMessage struct {
+	Text     string `json:"text"`
+	Markdown string `json:"markdown,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifLocation struct {
+	ID               *int                   `json:"id,omitempty"`
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	Message          *sarifMessage          `json:"message,omitempty"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion   `json:"deletedRegion"`
+	InsertedContent *sarifMessage `json:"insertedContent,omitempty"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Replacements     []sarifReplacement    `json:"replacements"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage          `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifResult struct {
+	RuleID           string          `json:"ruleId,omitempty"`
+	Level            string          `json:"level"`
+	Message          sarifMessage    `json:"message"`
+	Locations        []sarifLocation `json:"locations,omitempty"`
+	RelatedLocations []sarifLocation `json:"relatedLocations,omitempty"`
+	Fixes            []sarifFix      `json:"fixes,omitempty"`
+}
+
+type sarifRule struct {
+	ID               string        `json:"id"`
+	ShortDescription *sarifMessage `json:"shortDescription,omitempty"`
+	FullDescription  *sarifMessage `json:"fullDescription,omitempty"`
+	HelpURI          string        `json:"helpUri,omitempty"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules,omitempty"`
+}
+
+type sarifRun struct {
+	Tool struct {
+		Driver sarifDriver `json:"driver"`
+	} `json:"tool"`
+	ColumnKind string        `json:"columnKind"`
+	Results    []sarifResult `json:"results"`
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+// sarifURI converts the file path to URI. Absolute paths are converted to file:// URIs and
+// relative paths are converted to relative references.
+func sarifURI(path string) string {
+	p := filepath.ToSlash(path)
+	if !filepath.IsAbs(path) {
+		return (&url.URL{Path: p}).EscapedPath()
+	}
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p // Windows path such as C:/foo
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+func sarifLevel(s Severity) string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo, SeverityHint:
+		return "note"
+	default:
+		return "error"
+	}
+}
+
+// newSARIFRegion makes a region of the range. Columns are in UTF-16 code units.
+func newSARIFRegion(start, end Pos) *sarifRegion {
+	if start.Line < 1 {
+		return nil
+	}
+	r := &sarifRegion{StartLine: start.Line}
+	if c := start.ColumnIn(ColumnUTF16); c >= 1 {
+		r.StartColumn = c
+	}
+	if end.File != nil && end.Line >= start.Line {
+		r.EndLine = end.Line
+		if c := end.ColumnIn(ColumnUTF16); c >= 1 && r.StartColumn > 0 {
+			r.EndColumn = c
+		}
+	}
+	return r
+}
+
+func newSARIFPhysicalLocation(start, end Pos) *sarifPhysicalLocation {
+	if start.File == nil {
+		return nil
+	}
+	return &sarifPhysicalLocation{
+		ArtifactLocation: sarifArtifactLocation{sarifURI(start.File.Path)},
+		Region:           newSARIFRegion(start, end),
+	}
+}
+
+func newSARIFResult(err *Error) sarifResult {
+	res := sarifResult{
+		RuleID:  err.Code,
+		Level:   sarifLevel(err.Severity),
+		Message: sarifMessage{Text: err.Message},
+	}
+	if l := newSARIFPhysicalLocation(err.Start, err.End); l != nil {
+		res.Locations = []sarifLocation{{PhysicalLocation: l}}
+	}
+	for i, n := range err.Notes {
+		id := i
+		res.RelatedLocations = append(res.RelatedLocations, sarifLocation{
+			ID:               &id,
+			PhysicalLocation: newSARIFPhysicalLocation(n.Start, n.End),
+			Message:          &sarifMessage{Text: n.Kind.Label() + ": " + n.Message},
+		})
+	}
+	for i := range err.Suggestions {
+		s := &err.Suggestions[i]
+		if s.Range.Start.File == nil {
+			continue
+		}
+		end := s.Range.End
+		if end.File == nil {
+			end = s.Range.Start // Insertion
+		}
+		region := newSARIFRegion(s.Range.Start, end)
+		if region == nil || region.EndColumn == 0 {
+			continue // Region to be replaced is unknown
+		}
+		rep := sarifReplacement{DeletedRegion: *region}
+		if s.Replacement != "" {
+			rep.InsertedContent = &sarifMessage{Text: s.Replacement}
+		}
+		res.Fixes = append(res.Fixes, sarifFix{
+			Description: sarifMessage{Text: s.description()},
+			ArtifactChanges: []sarifArtifactChange{{
+				ArtifactLocation: sarifArtifactLocation{sarifURI(s.Range.Start.File.Path)},
+				Replacements:     []sarifReplacement{rep},
+			}},
+		})
+	}
+	return res
+}
+
+func newSARIFRules(reg *Registry, errs []*Error) []sarifRule {
+	seen := map[string]struct{}{}
+	codes := []string{}
+	for _, err := range errs {
+		if _, ok := seen[err.Code]; err.Code == "" || ok {
+			continue
+		}
+		seen[err.Code] = struct{}{}
+		codes = append(codes, err.Code)
+	}
+	sort.Strings(codes)
+
+	rules := []sarifRule{}
+	for _, c := range codes {
+		info, ok := reg.Lookup(c)
+		if !ok {
+			continue
+		}
+		r := sarifRule{ID: c, HelpURI: info.URL}
+		if info.Title != "" {
+			r.ShortDescription = &sarifMessage{Text: info.Title}
+		}
+		if e := strings.TrimSpace(info.Explanation); e != "" {
+			r.FullDescription = &sarifMessage{Text: e, Markdown: e}
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// WriteSARIF writes the errors as a SARIF 2.1.0 log to the given writer. The log has one run of
+// the tool. Each error is converted to a result. Its position is converted to a physical location,
+// its notes are converted to related locations and its suggestions are converted to fixes. Code of
+// the error is used as rule ID. Columns in the log are counted in UTF-16 code units as SARIF
+// requires by default.
+func WriteSARIF(w io.Writer, tool SARIFTool, errs []*Error) error {
+	run := sarifRun{ColumnKind: "utf16CodeUnits", Results: []sarifResult{}}
+	run.Tool.Driver = sarifDriver{Name: tool.Name, Version: tool.Version, InformationURI: tool.InformationURI}
+	if tool.Registry != nil {
+		run.Tool.Driver.Rules = newSARIFRules(tool.Registry, errs)
+	}
+	for _, err := range errs {
+		run.Results = append(run.Results, newSARIFResult(err))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(&sarifLog{Schema: sarifSchemaURI, Version: "2.1.0", Runs: []sarifRun{run}})
+}
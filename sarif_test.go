@@ -0,0 +1,336 @@
+package locerr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// schemaValidator validates JSON values with the subset of JSON Schema draft-04 used by the SARIF
+// schema. "format" keyword is not checked.
+type schemaValidator struct {
+	root map[string]interface{}
+}
+
+func (v *schemaValidator) resolve(ref string) (map[string]interface{}, error) {
+	if !strings.HasPrefix(ref, "#/definitions/") {
+		return nil, fmt.Errorf("unsupported $ref %q", ref)
+	}
+	defs := v.root["definitions"].(map[string]interface{})
+	s, ok := defs[strings.TrimPrefix(ref, "#/definitions/")].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unknown $ref %q", ref)
+	}
+	return s, nil
+}
+
+func jsonType(x interface{}) string {
+	switch x := x.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if x == float64(int64(x)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func (v *schemaValidator) validate(s map[string]interface{}, x interface{}, path string) error {
+	if ref, ok := s["$ref"].(string); ok {
+		r, err := v.resolve(ref)
+		if err != nil {
+			return err
+		}
+		return v.validate(r, x, path)
+	}
+
+	if t, ok := s["type"]; ok {
+		types := []interface{}{t}
+		if ts, ok := t.([]interface{}); ok {
+			types = ts
+		}
+		have := jsonType(x)
+		matched := false
+		for _, t := range types {
+			if t == have || t == "number" && have == "integer" {
+				matched = true
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: type should be %v but %s", path, t, have)
+		}
+	}
+
+	if e, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, c := range e {
+			if reflect.DeepEqual(c, x) {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not in enum %v", path, x, e)
+		}
+	}
+
+	for _, k := range []string{"anyOf", "oneOf"} {
+		subs, ok := s[k].([]interface{})
+		if !ok {
+			continue
+		}
+		n := 0
+		for _, sub := range subs {
+			if v.validate(sub.(map[string]interface{}), x, path) == nil {
+				n++
+			}
+		}
+		if n == 0 || k == "oneOf" && n > 1 {
+			return fmt.Errorf("%s: %d schemas in %s matched", path, n, k)
+		}
+	}
+
+	switch x := x.(type) {
+	case float64:
+		if m, ok := s["minimum"].(float64); ok && x < m {
+			return fmt.Errorf("%s: %v is less than minimum %v", path, x, m)
+		}
+		if m, ok := s["maximum"].(float64); ok && x > m {
+			return fmt.Errorf("%s: %v is greater than maximum %v", path, x, m)
+		}
+	case string:
+		if p, ok := s["pattern"].(string); ok && !regexp.MustCompile(p).MatchString(x) {
+			return fmt.Errorf("%s: %q does not match pattern %q", path, x, p)
+		}
+	case []interface{}:
+		if m, ok := s["minItems"].(float64); ok && len(x) < int(m) {
+			return fmt.Errorf("%s: array should have at least %v items", path, m)
+		}
+		if u, ok := s["uniqueItems"].(bool); ok && u {
+			for i := range x {
+				for j := i + 1; j < len(x); j++ {
+					if reflect.DeepEqual(x[i], x[j]) {
+						return fmt.Errorf("%s: items at %d and %d are not unique", path, i, j)
+					}
+				}
+			}
+		}
+		if items, ok := s["items"].(map[string]interface{}); ok {
+			for i, e := range x {
+				if err := v.validate(items, e, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case map[string]interface{}:
+		if req, ok := s["required"].([]interface{}); ok {
+			for _, r := range req {
+				if _, ok := x[r.(string)]; !ok {
+					return fmt.Errorf("%s: required property %q is missing", path, r)
+				}
+			}
+		}
+		props, _ := s["properties"].(map[string]interface{})
+		for k, e := range x {
+			if p, ok := props[k]; ok {
+				if err := v.validate(p.(map[string]interface{}), e, path+"."+k); err != nil {
+					return err
+				}
+				continue
+			}
+			switch a := s["additionalProperties"].(type) {
+			case bool:
+				if !a {
+					return fmt.Errorf("%s: additional property %q is not allowed", path, k)
+				}
+			case map[string]interface{}:
+				if err := v.validate(a, e, path+"."+k); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateSARIF(t *testing.T, log []byte) {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "sarif-schema-2.1.0.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		t.Fatal(err)
+	}
+	var x interface{}
+	if err := json.Unmarshal(log, &x); err != nil {
+		t.Fatal(err)
+	}
+	v := &schemaValidator{schema}
+	if err := v.validate(schema, x, "$"); err != nil {
+		t.Fatalf("SARIF log does not match schema: %s\n%s", err, log)
+	}
+}
+
+func TestSchemaValidator(t *testing.T) {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "sarif-schema-2.1.0.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(b, &schema); err != nil {
+		t.Fatal(err)
+	}
+	v := &schemaValidator{schema}
+
+	for _, input := range []string{
+		`{"runs":[]}`,
+		`{"version":"2.0.0","runs":[]}`,
+		`{"version":"2.1.0","runs":[{}]}`,
+		`{"version":"2.1.0","runs":[{"tool":{"driver":{}}}]}`,
+		`{"version":"2.1.0","runs":[{"tool":{"driver":{"name":"t"}},"results":[{"message":{"text":"m"},"level":"fatal"}]}]}`,
+		`{"version":"2.1.0","runs":[{"tool":{"driver":{"name":"t"}},"results":[{"message":{"text":"m"},"locations":[{"physicalLocation":{"region":{"startLine":0}}}]}]}]}`,
+		`{"version":"2.1.0","runs":[],"unknown":1}`,
+	} {
+		var x interface{}
+		if err := json.Unmarshal([]byte(input), &x); err != nil {
+			t.Fatal(err)
+		}
+		if err := v.validate(schema, x, "$"); err == nil {
+			t.Errorf("Invalid SARIF log was accepted: %s", input)
+		}
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	src := &Source{Path: "/path/to/test.txt", Code: []byte("let x = 1\nfoo(あ b)\n")}
+	other := &Source{Path: "lib/other file.txt", Code: []byte("def")}
+	reg := NewRegistry()
+	reg.Register(CodeInfo{Code: "E0001", Title: "Missing comma", Explanation: "Arguments must be separated with `,`.", URL: "https://example.com/E0001"})
+
+	errs := []*Error{
+		ErrorIn(src.PosAt(18), src.PosAt(19), "Missing comma").
+			WithCode("E0001").
+			NoteIn(other.PosAt(0), other.PosAt(3), "Defined here").
+			Help("Add comma").
+			Suggest(Range{Start: src.PosAt(17)}, ",", ""),
+		WarningAt(src.PosAt(4), "Unused variable").WithCode("W0002"),
+		InfoAt(src.PosAt(0), "Info"),
+		NewError("Without position"),
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, SARIFTool{Name: "test", Version: "1.0.0", Registry: reg}, errs); err != nil {
+		t.Fatal(err)
+	}
+	validateSARIF(t, buf.Bytes())
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != "test" || run.Tool.Driver.Version != "1.0.0" {
+		t.Fatal("Unexpected driver:", run.Tool.Driver)
+	}
+	rules := []sarifRule{
+		{
+			ID:               "E0001",
+			ShortDescription: &sarifMessage{Text: "Missing comma"},
+			FullDescription:  &sarifMessage{Text: "Arguments must be separated with `,`.", Markdown: "Arguments must be separated with `,`."},
+			HelpURI:          "https://example.com/E0001",
+		},
+	}
+	if !reflect.DeepEqual(run.Tool.Driver.Rules, rules) {
+		t.Fatalf("Unexpected rules: %+v", run.Tool.Driver.Rules)
+	}
+	if len(run.Results) != 4 {
+		t.Fatal("Unexpected number of results:", len(run.Results))
+	}
+
+	zero := 0
+	one := 1
+	want := sarifResult{
+		RuleID:  "E0001",
+		Level:   "error",
+		Message: sarifMessage{Text: "Missing comma"},
+		Locations: []sarifLocation{{
+			PhysicalLocation: &sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{"file:///path/to/test.txt"},
+				// Column is in UTF-16 code units. U+3042 is 3 bytes in UTF-8 and 1 code unit in UTF-16
+				Region: &sarifRegion{StartLine: 2, StartColumn: 7, EndLine: 2, EndColumn: 8},
+			},
+		}},
+		RelatedLocations: []sarifLocation{
+			{
+				ID: &zero,
+				PhysicalLocation: &sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{"lib/other%20file.txt"},
+					Region:           &sarifRegion{StartLine: 1, StartColumn: 1, EndLine: 1, EndColumn: 4},
+				},
+				Message: &sarifMessage{Text: "Note: Defined here"},
+			},
+			{
+				ID:      &one,
+				Message: &sarifMessage{Text: "Help: Add comma"},
+			},
+		},
+		Fixes: []sarifFix{{
+			Description: sarifMessage{Text: "insert `,`"},
+			ArtifactChanges: []sarifArtifactChange{{
+				ArtifactLocation: sarifArtifactLocation{"file:///path/to/test.txt"},
+				Replacements: []sarifReplacement{{
+					DeletedRegion:   sarifRegion{StartLine: 2, StartColumn: 6, EndLine: 2, EndColumn: 6},
+					InsertedContent: &sarifMessage{Text: ","},
+				}},
+			}},
+		}},
+	}
+	if !reflect.DeepEqual(run.Results[0], want) {
+		have, _ := json.MarshalIndent(run.Results[0], "", "  ")
+		t.Fatalf("Unexpected result:\n%s", have)
+	}
+
+	for i, tc := range []struct {
+		level string
+		rule  string
+	}{
+		{"warning", "W0002"},
+		{"note", ""},
+		{"error", ""},
+	} {
+		r := run.Results[i+1]
+		if r.Level != tc.level || r.RuleID != tc.rule {
+			t.Errorf("Unexpected level or rule at %d: %+v", i+1, r)
+		}
+	}
+	if run.Results[3].Locations != nil {
+		t.Error("Error without position should have no location:", run.Results[3].Locations)
+	}
+}
+
+func TestWriteSARIFEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, SARIFTool{Name: "test"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	validateSARIF(t, buf.Bytes())
+	if !strings.Contains(buf.String(), `"results": []`) {
+		t.Fatal("Results should be empty array:", buf.String())
+	}
+}
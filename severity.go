@@ -0,0 +1,136 @@
+package locerr
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+)
+
+// Severity represents how serious the error is. Zero value is SeverityError.
+type Severity int
+
+const (
+	// SeverityError is a severity for errors. This is the default severity.
+	SeverityError Severity = iota
+	// SeverityWarning is a severity for warnings.
+	SeverityWarning
+	// SeverityInfo is a severity for informational messages.
+	SeverityInfo
+	// SeverityHint is a severity for hints such as lints.
+	SeverityHint
+)
+
+var (
+	yellow = color.New(color.FgYellow)
+	blue   = color.New(color.FgBlue)
+	cyan   = color.New(color.FgCyan)
+)
+
+// String returns the name of the severity in lower case such as "error" or "warning".
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "info"
+	case SeverityHint:
+		return "hint"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// Label returns the label of the severity shown at the head of error message such as "Error".
+func (s Severity) Label() string {
+	switch s {
+	case SeverityWarning:
+		return "Warning"
+	case SeverityInfo:
+		return "Info"
+	case SeverityHint:
+		return "Hint"
+	default:
+		return "Error"
+	}
+}
+
+func (s Severity) color() *color.Color {
+	switch s {
+	case SeverityWarning:
+		return yellow
+	case SeverityInfo:
+		return blue
+	case SeverityHint:
+		return cyan
+	default:
+		return red
+	}
+}
+
+// WarningIn makes a new warning with the range.
+func WarningIn(start, end Pos, msg string) *Error {
+	err := ErrorIn(start, end, msg)
+	err.Severity = SeverityWarning
+	return err
+}
+
+// WarningAt makes a new warning with the position.
+func WarningAt(pos Pos, msg string) *Error {
+	return WarningIn(pos, Pos{}, msg)
+}
+
+// WarningfIn makes a new warning with the range and formatted message.
+func WarningfIn(start, end Pos, format string, args ...interface{}) *Error {
+	return WarningIn(start, end, fmt.Sprintf(format, args...))
+}
+
+// WarningfAt makes a new warning with the position and formatted message.
+func WarningfAt(pos Pos, format string, args ...interface{}) *Error {
+	return WarningIn(pos, Pos{}, fmt.Sprintf(format, args...))
+}
+
+// InfoIn makes a new informational message with the range.
+func InfoIn(start, end Pos, msg string) *Error {
+	err := ErrorIn(start, end, msg)
+	err.Severity = SeverityInfo
+	return err
+}
+
+// InfoAt makes a new informational message with the position.
+func InfoAt(pos Pos, msg string) *Error {
+	return InfoIn(pos, Pos{}, msg)
+}
+
+// InfofIn makes a new informational message with the range and formatted message.
+func InfofIn(start, end Pos, format string, args ...interface{}) *Error {
+	return InfoIn(start, end, fmt.Sprintf(format, args...))
+}
+
+// InfofAt makes a new informational message with the position and formatted message.
+func InfofAt(pos Pos, format string, args ...interface{}) *Error {
+	return InfoIn(pos, Pos{}, fmt.Sprintf(format, args...))
+}
+
+// HintIn makes a new hint with the range.
+func HintIn(start, end Pos, msg string) *Error {
+	err := ErrorIn(start, end, msg)
+	err.Severity = SeverityHint
+	return err
+}
+
+// HintAt makes a new hint with the position.
+func HintAt(pos Pos, msg string) *Error {
+	return HintIn(pos, Pos{}, msg)
+}
+
+// HintfIn makes a new hint with the range and formatted message.
+func HintfIn(start, end Pos, format string, args ...interface{}) *Error {
+	return HintIn(start, end, fmt.Sprintf(format, args...))
+}
+
+// HintfAt makes a new hint with the position and formatted message.
+func HintfAt(pos Pos, format string, args ...interface{}) *Error {
+	return HintIn(pos, Pos{}, fmt.Sprintf(format, args...))
+}
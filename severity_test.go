@@ -0,0 +1,64 @@
+package locerr
+
+import (
+	"testing"
+)
+
+func TestSeverityLabel(t *testing.T) {
+	src := NewDummySource("abc\ndef")
+	s := Pos{4, 2, 1, src}
+	e := Pos{6, 2, 3, src}
+	loc := " (at <dummy>:2:1)"
+	snip := "\n\n> def\n"
+
+	cases := []struct {
+		what string
+		err  *Error
+		want string
+	}{
+		{"default", NewError("text"), "Error: text"},
+		{"ErrorIn", ErrorIn(s, e, "text"), "Error: text" + loc + snip},
+		{"WarningIn", WarningIn(s, e, "text"), "Warning: text" + loc + snip},
+		{"WarningAt", WarningAt(s, "text"), "Warning: text" + loc + snip},
+		{"WarningfIn", WarningfIn(s, e, "text %d", 42), "Warning: text 42" + loc + snip},
+		{"WarningfAt", WarningfAt(s, "text %d", 42), "Warning: text 42" + loc + snip},
+		{"InfoIn", InfoIn(s, e, "text"), "Info: text" + loc + snip},
+		{"InfoAt", InfoAt(s, "text"), "Info: text" + loc + snip},
+		{"InfofIn", InfofIn(s, e, "text %d", 42), "Info: text 42" + loc + snip},
+		{"InfofAt", InfofAt(s, "text %d", 42), "Info: text 42" + loc + snip},
+		{"HintIn", HintIn(s, e, "text"), "Hint: text" + loc + snip},
+		{"HintAt", HintAt(s, "text"), "Hint: text" + loc + snip},
+		{"HintfIn", HintfIn(s, e, "text %d", 42), "Hint: text 42" + loc + snip},
+		{"HintfAt", HintfAt(s, "text %d", 42), "Hint: text 42" + loc + snip},
+		{"with code", ErrorAt(s, "text").WithCode("E0042"), "Error[E0042]: text" + loc + snip},
+		{"warning with code", WarningAt(s, "text").WithCode("W001"), "Warning[W001]: text" + loc + snip},
+		{"code without position", NewError("text").WithCode("E0042"), "Error[E0042]: text"},
+		{"note keeps severity", Note(WarningAt(s, "text"), "note"), "Warning: text" + loc + snip + "\n  Note: note"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.what, func(t *testing.T) {
+			have := tc.err.Error()
+			if have != tc.want {
+				t.Fatalf("Unexpected error message.\nwant:\n'%s'\nhave:\n'%s'", tc.want, have)
+			}
+		})
+	}
+}
+
+func TestSeverityString(t *testing.T) {
+	for _, tc := range []struct {
+		sev  Severity
+		want string
+	}{
+		{SeverityError, "error"},
+		{SeverityWarning, "warning"},
+		{SeverityInfo, "info"},
+		{SeverityHint, "hint"},
+		{Severity(42), "severity(42)"},
+	} {
+		if have := tc.sev.String(); have != tc.want {
+			t.Errorf("Wanted '%s' but have '%s'", tc.want, have)
+		}
+	}
+}
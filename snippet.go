@@ -0,0 +1,478 @@
+package locerr
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// SnippetStyle is a style of code snippet shown in error message.
+type SnippetStyle int
+
+const (
+	// SnippetPrefix shows each line of snippet with '> ' prefix and emphasizes the range with color.
+	// This is the default style.
+	//
+	//   > foo(true,
+	//   >     42)
+	SnippetPrefix SnippetStyle = iota
+	// SnippetGutter shows line numbers in gutter and underlines the range with '^~~~' like rustc or
+	// clang. The range can be seen even if color is disabled.
+	//
+	//   6 | foo(true,
+	//     |     ^~~~~
+	//   7 |     42)
+	//     |     ~~
+	SnippetGutter
+)
+
+// Options is a set of options to render error messages.
+type Options struct {
+	// Style is a style of code snippets. Default value is SnippetPrefix.
+	Style SnippetStyle
+	// ContextBefore is the number of lines shown before the snippet as context.
+	ContextBefore int
+	// ContextAfter is the number of lines shown after the snippet as context.
+	ContextAfter int
+	// EdgeLines limits the number of lines in a snippet. When it is positive and a range is too long,
+	// only the first EdgeLines lines and the last EdgeLines lines of the range are shown and lines
+	// between them are replaced with '...'.
+	EdgeLines int
+	// MaxWidth is the maximum width of a line in snippet. When it is positive, lines wider than it are
+	// cropped to a window around the range and cropped sides are marked with '…'. Width is measured
+	// in terminal columns.
+	MaxWidth int
+	// TabWidth is the width of tab stop. Tabs in snippet are expanded to spaces in SnippetGutter
+	// style so that marks are aligned with the code. When it is not positive, 4 is used.
+	TabWidth int
+}
+
+var options Options
+
+// SetOptions sets options to render error messages. Like SetColor, it affects all error messages
+// rendered after the call.
+func SetOptions(opts Options) {
+	options = opts
+}
+
+// snipLine is one line of code snippet.
+type snipLine struct {
+	// Line number
+	num int
+	// Offset of the head of the line in source
+	offset int
+	// Content of the line without newline
+	text []byte
+	// Highlighted part of the line is text[start:end]
+	start int
+	end   int
+	// head is true when the line contains the start of the range. '^' is put at start of the line
+	head bool
+	// context is true when the line is not a part of the range but surrounding context
+	context bool
+	// omitted is the number of lines omitted at this line. When it is positive, this line is a marker
+	// of elision
+	omitted int
+}
+
+func lineHeadOffset(code []byte, offset int) int {
+	for offset > 0 && code[offset-1] != '\n' {
+		offset--
+	}
+	return offset
+}
+
+func lineEndOffset(code []byte, offset int) int {
+	for offset < len(code) && code[offset] != '\n' {
+		offset++
+	}
+	return offset
+}
+
+// snipLinesIn collects lines which the range [from, to) covers.
+func snipLinesIn(from, to Pos) []snipLine {
+	code := from.File.Code
+	lines := []snipLine{}
+	num := from.Line
+	head := lineHeadOffset(code, from.Offset)
+	for {
+		end := lineEndOffset(code, head)
+		l := snipLine{num: num, offset: head, text: code[head:end], end: end - head}
+		if head <= from.Offset {
+			l.start = from.Offset - head
+			l.head = true
+		}
+		if to.Offset <= end {
+			l.end = to.Offset - head
+			return append(lines, l)
+		}
+		lines = append(lines, l)
+		head = end + 1
+		num++
+	}
+}
+
+// Show line based on pos.Line. We don't use offset for this because some environment offset
+// cannot be obtained (e.g. getting location from runtime.Caller).
+func snipLineAt(pos Pos) (snipLine, bool) {
+	code := pos.File.Code
+	if len(code) == 0 {
+		return snipLine{}, false
+	}
+
+	start := pos.File.LineStart(pos.Line)
+	if start == -1 {
+		return snipLine{}, false
+	}
+
+	end := lineEndOffset(code, start)
+	if start == end {
+		// Snippet is empty. Skipped.
+		return snipLine{}, false
+	}
+
+	col := pos.Column - 1
+	if col > end-start {
+		col = end - start
+	}
+	l := snipLine{num: pos.Line, offset: start, text: code[start:end]}
+	if col >= 0 {
+		l.start, l.end, l.head = col, col, true
+	}
+	return l, true
+}
+
+// elide omits lines between the first edge lines and the last edge lines. Lines are not omitted
+// when only one line would be omitted since the marker also occupies one line.
+func elide(lines []snipLine, edge int) []snipLine {
+	omitted := len(lines) - edge*2
+	if omitted < 2 {
+		return lines
+	}
+	elided := make([]snipLine, 0, edge*2+1)
+	elided = append(elided, lines[:edge]...)
+	elided = append(elided, snipLine{omitted: omitted})
+	return append(elided, lines[len(lines)-edge:]...)
+}
+
+// expandTabs replaces tabs in the line with spaces to the next tab stop.
+func (l *snipLine) expandTabs(tabWidth int) {
+	if bytes.IndexByte(l.text, '\t') < 0 {
+		return
+	}
+	b := make([]byte, 0, len(l.text)+tabWidth)
+	start, end := l.start, l.end
+	col := 0
+	for i := 0; i < len(l.text); {
+		r, s := utf8.DecodeRune(l.text[i:])
+		// Offsets may point to the middle of a character. Bytes other than tabs are copied as-is
+		if i <= l.start && l.start < i+s {
+			start = len(b) + l.start - i
+		}
+		if i <= l.end && l.end < i+s {
+			end = len(b) + l.end - i
+		}
+		if r == '\t' {
+			n := tabWidth - col%tabWidth
+			b = append(b, strings.Repeat(" ", n)...)
+			col += n
+		} else {
+			b = append(b, l.text[i:i+s]...)
+			col += runeWidth(r)
+		}
+		i += s
+	}
+	if l.start == len(l.text) {
+		start = len(b)
+	}
+	if l.end == len(l.text) {
+		end = len(b)
+	}
+	l.text, l.start, l.end = b, start, end
+}
+
+// snapToRunes moves the offsets in the middle of a character to the boundaries of the character
+// so that the character is highlighted as a whole.
+func (l *snipLine) snapToRunes() {
+	for i := 0; i < len(l.text); {
+		_, s := utf8.DecodeRune(l.text[i:])
+		if i < l.start && l.start < i+s {
+			l.start = i
+		}
+		if i < l.end && l.end < i+s {
+			l.end = i + s
+		}
+		i += s
+	}
+}
+
+// crop crops the line to the window of width columns around the anchor column. Cropped sides are
+// marked with '…'. Highlighted part of the line is clamped to the window.
+func (l *snipLine) crop(anchor, width int) {
+	total := displayWidth(l.text)
+	if total <= width {
+		return
+	}
+
+	left := anchor - width/4
+	if left+width > total {
+		left = total - width
+	}
+	if left < 0 {
+		left = 0
+	}
+	right := left + width
+
+	// Code in columns [lo, hi) is visible after cropping
+	lo, hi := left, right
+	if left > 0 {
+		lo++
+	}
+	if right < total {
+		hi--
+	}
+	clamp := func(c int) int {
+		if c < lo {
+			return lo
+		}
+		if c > hi {
+			return hi
+		}
+		return c
+	}
+	sc := clamp(displayWidth(l.text[:l.start]))
+	ec := clamp(displayWidth(l.text[:l.end]))
+
+	b := make([]byte, 0, width*utf8.UTFMax)
+	if left > 0 {
+		b = append(b, "…"...)
+	}
+	start, end := -1, -1
+	col, next := 0, lo
+	for i := 0; i < len(l.text); {
+		r, s := utf8.DecodeRune(l.text[i:])
+		w := runeWidth(r)
+		if lo <= col && col+w <= hi {
+			if col > next {
+				// Wide character was cut at the edge of window. Fill the column to keep alignment
+				b = append(b, strings.Repeat(" ", col-next)...)
+			}
+			if start < 0 && col >= sc {
+				start = len(b)
+			}
+			if end < 0 && col >= ec {
+				end = len(b)
+			}
+			b = append(b, l.text[i:i+s]...)
+			next = col + w
+		}
+		col += w
+		i += s
+	}
+	if start < 0 {
+		start = len(b)
+	}
+	if end < 0 {
+		end = len(b)
+	}
+	if right < total {
+		b = append(b, "…"...)
+	}
+	l.text, l.start, l.end = b, start, end
+}
+
+// crop crops all lines wider than width. All lines are cropped around the start column of the
+// range so that the same columns are shown in the lines.
+func crop(lines []snipLine, width int) {
+	if width < 3 {
+		// At least one column is necessary between two '…'
+		width = 3
+	}
+
+	// Characters are not split by cropping
+	for i := range lines {
+		lines[i].snapToRunes()
+	}
+
+	anchor := 0
+	for i := range lines {
+		if l := &lines[i]; l.head {
+			anchor = displayWidth(l.text[:l.start])
+			break
+		}
+	}
+
+	for i := range lines {
+		lines[i].crop(anchor, width)
+	}
+}
+
+// withContext surrounds the lines with at most before and after lines in the source as context.
+func withContext(code []byte, lines []snipLine, before, after int) []snipLine {
+	first, last := lines[0], lines[len(lines)-1]
+
+	ctx := []snipLine{}
+	for i, o := 0, first.offset; i < before && o > 0; i++ {
+		end := o - 1
+		o = lineHeadOffset(code, end)
+		ctx = append(ctx, snipLine{num: first.num - i - 1, offset: o, text: code[o:end], context: true})
+	}
+	// Context lines were collected from bottom to top
+	for i, j := 0, len(ctx)-1; i < j; i, j = i+1, j-1 {
+		ctx[i], ctx[j] = ctx[j], ctx[i]
+	}
+	ctx = append(ctx, lines...)
+
+	for i, o := 0, last.offset+len(last.text)+1; i < after && o < len(code); i++ {
+		end := lineEndOffset(code, o)
+		ctx = append(ctx, snipLine{num: last.num + i + 1, offset: o, text: code[o:end], context: true})
+		o = end + 1
+	}
+
+	return ctx
+}
+
+func writeSnipLine(w io.Writer, line string) {
+	indent, len := 0, len(line)
+	for indent < len {
+		if line[indent] != ' ' && line[indent] != '\t' {
+			break
+		}
+		indent++
+	}
+	if indent != 0 {
+		// Write indent without emphasis
+		fmt.Fprint(w, line[:indent])
+	}
+	if indent != len {
+		// Write code snip with emphasis
+		emphasis.Fprint(w, line[indent:])
+	}
+}
+
+func writePrefixSnip(w io.Writer, lines []snipLine) {
+	for i, l := range lines {
+		if i > 0 {
+			fmt.Fprint(w, "\n")
+		}
+		fmt.Fprint(w, "> ")
+		if l.omitted > 0 {
+			gray.Fprintf(w, "... (%d lines omitted)", l.omitted)
+			continue
+		}
+		if l.context {
+			gray.Fprint(w, string(l.text))
+			continue
+		}
+		w.Write(l.text[:l.start])
+		writeSnipLine(w, string(l.text[l.start:l.end]))
+		w.Write(l.text[l.end:])
+	}
+}
+
+// underline makes '^~~~' marks to put under the highlighted part of the line. Empty string is
+// returned when nothing should be marked.
+func (l *snipLine) underline() string {
+	start := l.start
+	if !l.head {
+		// Do not underline indentation
+		for start < l.end && (l.text[start] == ' ' || l.text[start] == '\t') {
+			start++
+		}
+		if start == l.end {
+			return ""
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.Repeat(" ", displayWidth(l.text[:start])))
+
+	n := displayWidth(l.text[start:l.end])
+	if l.head {
+		b.WriteRune('^')
+		n--
+	}
+	if n > 0 {
+		b.WriteString(strings.Repeat("~", n))
+	}
+	return b.String()
+}
+
+func writeGutterSnip(w io.Writer, lines []snipLine) {
+	width := len(fmt.Sprint(lines[len(lines)-1].num))
+	for i := range lines {
+		l := &lines[i]
+		if i > 0 {
+			fmt.Fprint(w, "\n")
+		}
+		if l.omitted > 0 {
+			gray.Fprintf(w, "  %*s | ... (%d lines omitted)", width, "", l.omitted)
+			continue
+		}
+		gray.Fprintf(w, "  %*d |", width, l.num)
+		if len(l.text) > 0 {
+			fmt.Fprint(w, " ")
+			if l.context {
+				gray.Fprint(w, string(l.text))
+				continue
+			}
+			w.Write(l.text)
+		}
+		if u := l.underline(); u != "" {
+			gray.Fprintf(w, "\n  %*s |", width, "")
+			fmt.Fprint(w, " ")
+			marker.Fprint(w, u)
+		}
+	}
+}
+
+// writeSnippet writes code snippet for the range. When the range is empty, the line at start
+// position is written. Note that start and end may be in a different file from the error.
+func writeSnippet(w io.Writer, start, end Pos, opts *Options) {
+	if start.File == nil {
+		return
+	}
+
+	var lines []snipLine
+	if end.File == nil || start.Offset == end.Offset || start.Offset > end.Offset || len(start.File.Code) < end.Offset {
+		l, ok := snipLineAt(start)
+		if !ok {
+			return
+		}
+		lines = []snipLine{l}
+	} else {
+		lines = snipLinesIn(start, end)
+		if opts.EdgeLines > 0 {
+			lines = elide(lines, opts.EdgeLines)
+		}
+	}
+
+	if opts.ContextBefore > 0 || opts.ContextAfter > 0 {
+		lines = withContext(start.File.Code, lines, opts.ContextBefore, opts.ContextAfter)
+	}
+
+	if opts.Style == SnippetGutter {
+		tw := opts.TabWidth
+		if tw <= 0 {
+			tw = 4
+		}
+		for i := range lines {
+			lines[i].expandTabs(tw)
+		}
+	}
+
+	if opts.MaxWidth > 0 {
+		crop(lines, opts.MaxWidth)
+	}
+
+	fmt.Fprint(w, "\n\n")
+	switch opts.Style {
+	case SnippetGutter:
+		writeGutterSnip(w, lines)
+	default:
+		writePrefixSnip(w, lines)
+	}
+	fmt.Fprint(w, "\n")
+}
@@ -0,0 +1,679 @@
+package locerr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGutterSnippet(t *testing.T) {
+	defer func(saved Options) { options = saved }(options)
+	SetOptions(Options{Style: SnippetGutter})
+
+	cases := []struct {
+		what string
+		code string
+		from int
+		to   int
+		want []string
+	}{
+		{
+			what: "whole in a line",
+			code: "abc",
+			from: 0,
+			to:   3,
+			want: []string{
+				"  1 | abc",
+				"    | ^~~",
+			},
+		},
+		{
+			what: "slice in a line",
+			code: "abc",
+			from: 1,
+			to:   2,
+			want: []string{
+				"  1 | abc",
+				"    |  ^",
+			},
+		},
+		{
+			what: "slice in a line with indent",
+			code: "\t abc",
+			from: 3,
+			to:   5,
+			want: []string{
+				"  1 |      abc",
+				"    |       ^~",
+			},
+		},
+		{
+			what: "multi-byte characters",
+			code: "あい = うえお",
+			from: 9,
+			to:   18,
+			want: []string{
+				"  1 | あい = うえお",
+				"    |        ^~~~~~",
+			},
+		},
+		{
+			what: "partial two lines",
+			code: "aaa\nbbb",
+			from: 2,
+			to:   5,
+			want: []string{
+				"  1 | aaa",
+				"    |   ^",
+				"  2 | bbb",
+				"    | ~",
+			},
+		},
+		{
+			what: "indented lines",
+			code: "foo(a,\n    b,\n    c)",
+			from: 4,
+			to:   19,
+			want: []string{
+				"  1 | foo(a,",
+				"    |     ^~",
+				"  2 |     b,",
+				"    |     ~~",
+				"  3 |     c)",
+				"    |     ~",
+			},
+		},
+		{
+			what: "start on newline",
+			code: "aaa\nbbb",
+			from: 3,
+			to:   7,
+			want: []string{
+				"  1 | aaa",
+				"    |    ^",
+				"  2 | bbb",
+				"    | ~~~",
+			},
+		},
+		{
+			what: "end just after newline",
+			code: "aaa\nbbb",
+			from: 1,
+			to:   4,
+			want: []string{
+				"  1 | aaa",
+				"    |  ^~",
+				"  2 | bbb",
+			},
+		},
+		{
+			what: "containing empty lines",
+			code: "aaa\n\nccc",
+			from: 0,
+			to:   8,
+			want: []string{
+				"  1 | aaa",
+				"    | ^~~",
+				"  2 |",
+				"  3 | ccc",
+				"    | ~~~",
+			},
+		},
+		{
+			what: "gutter width",
+			code: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11",
+			from: 16,
+			to:   23,
+			want: []string{
+				"   9 | 9",
+				"     | ^",
+				"  10 | 10",
+				"     | ~~",
+				"  11 | 11",
+				"     | ~~",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.what, func(t *testing.T) {
+			src := NewDummySource(tc.code)
+			err := ErrorIn(src.PosAt(tc.from), src.PosAt(tc.to), "text")
+			have := strings.SplitN(err.Error(), "\n", 3)[2]
+			want := strings.Join(tc.want, "\n") + "\n"
+			if have != want {
+				t.Fatalf("Unexpected snippet\n\nwant:\n'%s'\nhave:\n'%s'", want, have)
+			}
+		})
+	}
+}
+
+func TestGutterOnelineSnippet(t *testing.T) {
+	defer func(saved Options) { options = saved }(options)
+	SetOptions(Options{Style: SnippetGutter})
+
+	src := NewDummySource("aaa\nfoo(true,\nccc")
+	for _, tc := range []struct {
+		what string
+		pos  Pos
+		want string
+	}{
+		{"column", Pos{8, 2, 5, src}, "  2 | foo(true,\n    |     ^\n"},
+		{"head of line", Pos{4, 2, 1, src}, "  2 | foo(true,\n    | ^\n"},
+		{"column exceeds line", Pos{4, 2, 42, src}, "  2 | foo(true,\n    |          ^\n"},
+		{"unknown column", Pos{0, 2, 0, src}, "  2 | foo(true,\n"},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			have := strings.SplitN(ErrorAt(tc.pos, "text").Error(), "\n", 3)[2]
+			if have != tc.want {
+				t.Fatalf("Unexpected snippet\n\nwant:\n'%s'\nhave:\n'%s'", tc.want, have)
+			}
+		})
+	}
+}
+
+func TestContextLines(t *testing.T) {
+	defer func(saved Options) { options = saved }(options)
+
+	code := "l1\nl2\nl3\nl4\nl5\nl6\n"
+	src := NewDummySource(code)
+
+	cases := []struct {
+		what    string
+		opts    Options
+		from    int
+		to      int
+		oneline bool
+		want    []string
+	}{
+		{
+			what: "before and after",
+			opts: Options{ContextBefore: 1, ContextAfter: 1},
+			from: 6,
+			to:   8,
+			want: []string{"> l2", "> l3", "> l4"},
+		},
+		{
+			what: "multiple lines",
+			opts: Options{ContextBefore: 2, ContextAfter: 2},
+			from: 6,
+			to:   11,
+			want: []string{"> l1", "> l2", "> l3", "> l4", "> l5", "> l6"},
+		},
+		{
+			what: "clipped at beginning of file",
+			opts: Options{ContextBefore: 3},
+			from: 3,
+			to:   5,
+			want: []string{"> l1", "> l2"},
+		},
+		{
+			what: "clipped at end of file",
+			opts: Options{ContextAfter: 3},
+			from: 12,
+			to:   14,
+			want: []string{"> l5", "> l6"},
+		},
+		{
+			what:    "oneline",
+			opts:    Options{ContextBefore: 1, ContextAfter: 1},
+			from:    0,
+			oneline: true,
+			want:    []string{"> l1", "> l2"},
+		},
+		{
+			what: "with gutter",
+			opts: Options{Style: SnippetGutter, ContextBefore: 1, ContextAfter: 1},
+			from: 6,
+			to:   8,
+			want: []string{"  2 | l2", "  3 | l3", "    | ^~", "  4 | l4"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.what, func(t *testing.T) {
+			SetOptions(tc.opts)
+			var err *Error
+			if tc.oneline {
+				err = ErrorAt(src.PosAt(tc.from), "text")
+			} else {
+				err = ErrorIn(src.PosAt(tc.from), src.PosAt(tc.to), "text")
+			}
+			have := strings.SplitN(err.Error(), "\n", 3)[2]
+			want := strings.Join(tc.want, "\n") + "\n"
+			if have != want {
+				t.Fatalf("Unexpected snippet\n\nwant:\n'%s'\nhave:\n'%s'", want, have)
+			}
+		})
+	}
+}
+
+func TestContextLinesWithoutTrailingNewline(t *testing.T) {
+	defer func(saved Options) { options = saved }(options)
+	SetOptions(Options{Style: SnippetGutter, ContextBefore: 1, ContextAfter: 1})
+
+	src := NewDummySource("aaa\n\nbbb")
+	err := ErrorIn(src.PosAt(5), src.PosAt(8), "text")
+	have := strings.SplitN(err.Error(), "\n", 3)[2]
+	want := "  2 |\n  3 | bbb\n    | ^~~\n"
+	if have != want {
+		t.Fatalf("Unexpected snippet\n\nwant:\n'%s'\nhave:\n'%s'", want, have)
+	}
+}
+
+func TestElidedSnippet(t *testing.T) {
+	defer func(saved Options) { options = saved }(options)
+	SetOptions(Options{EdgeLines: 1})
+
+	cases := []struct {
+		what string
+		code string
+		from int
+		to   int
+		want []string
+	}{
+		{
+			what: "whole in a line",
+			code: "abc",
+			from: 0,
+			to:   2,
+			want: []string{
+				"> abc",
+			},
+		},
+		{
+			what: "whole two lines",
+			code: "aaa\nbbb",
+			from: 0,
+			to:   7,
+			want: []string{
+				"> aaa",
+				"> bbb",
+			},
+		},
+		{
+			what: "three lines are not elided",
+			code: "aaa\nbbb\nccc",
+			from: 0,
+			to:   11,
+			want: []string{
+				"> aaa",
+				"> bbb",
+				"> ccc",
+			},
+		},
+		{
+			what: "whole multi lines",
+			code: "aaa\nbbb\nccc\nddd\neee",
+			from: 0,
+			to:   19,
+			want: []string{
+				"> aaa",
+				"> ... (3 lines omitted)",
+				"> eee",
+			},
+		},
+		{
+			what: "start on newline",
+			code: "aaa\nbbb\nccc\nddd",
+			from: 3,
+			to:   15,
+			want: []string{
+				"> aaa",
+				"> ... (2 lines omitted)",
+				"> ddd",
+			},
+		},
+		{
+			what: "start just after newline",
+			code: "aaa\nbbb\nccc\nddd\neee",
+			from: 4,
+			to:   19,
+			want: []string{
+				"> bbb",
+				"> ... (2 lines omitted)",
+				"> eee",
+			},
+		},
+		{
+			what: "end on newline",
+			code: "aaa\nbbb\nccc\nddd\neee",
+			from: 0,
+			to:   15,
+			want: []string{
+				"> aaa",
+				"> ... (2 lines omitted)",
+				"> ddd",
+			},
+		},
+		{
+			what: "end just after newline",
+			code: "aaa\nbbb\nccc\nddd\neee",
+			from: 1,
+			to:   12,
+			want: []string{
+				"> aaa",
+				"> ... (2 lines omitted)",
+				"> ddd",
+			},
+		},
+		{
+			what: "containing empty lines",
+			code: "aaa\n\n\nccc\n\neee",
+			from: 2,
+			to:   13,
+			want: []string{
+				"> aaa",
+				"> ... (4 lines omitted)",
+				"> eee",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.what, func(t *testing.T) {
+			src := NewDummySource(tc.code)
+			err := ErrorIn(src.PosAt(tc.from), src.PosAt(tc.to), "text")
+			have := strings.SplitN(err.Error(), "\n", 3)[2]
+			want := strings.Join(tc.want, "\n") + "\n"
+			if have != want {
+				t.Fatalf("Unexpected snippet\n\nwant:\n'%s'\nhave:\n'%s'", want, have)
+			}
+		})
+	}
+}
+
+func TestElidedSnippetWithGutterAndContext(t *testing.T) {
+	defer func(saved Options) { options = saved }(options)
+	SetOptions(Options{Style: SnippetGutter, EdgeLines: 2, ContextBefore: 1, ContextAfter: 1})
+
+	lines := make([]string, 0, 400)
+	for i := 1; i <= 400; i++ {
+		lines = append(lines, "x")
+	}
+	src := NewDummySource(strings.Join(lines, "\n"))
+	// From line 2 to line 399
+	err := ErrorIn(src.PosAt(2), src.PosAt(797), "text")
+	have := strings.SplitN(err.Error(), "\n", 3)[2]
+	want := strings.Join([]string{
+		"    1 | x",
+		"    2 | x",
+		"      | ^",
+		"    3 | x",
+		"      | ~",
+		"      | ... (394 lines omitted)",
+		"  398 | x",
+		"      | ~",
+		"  399 | x",
+		"      | ~",
+		"  400 | x",
+	}, "\n") + "\n"
+	if have != want {
+		t.Fatalf("Unexpected snippet\n\nwant:\n'%s'\nhave:\n'%s'", want, have)
+	}
+}
+
+func TestCroppedSnippet(t *testing.T) {
+	defer func(saved Options) { options = saved }(options)
+
+	long := strings.Repeat("a", 40) + "foo(bar)" + strings.Repeat("b", 40)
+	cases := []struct {
+		what string
+		opts Options
+		code string
+		from int
+		to   int
+		want []string
+	}{
+		{
+			what: "not cropped",
+			opts: Options{Style: SnippetGutter, MaxWidth: 20},
+			code: "foo(bar)",
+			from: 4,
+			to:   7,
+			want: []string{
+				"  1 | foo(bar)",
+				"    |     ^~~",
+			},
+		},
+		{
+			what: "both sides",
+			opts: Options{Style: SnippetGutter, MaxWidth: 20},
+			code: long,
+			from: 44,
+			to:   47,
+			want: []string{
+				"  1 | …foo(bar)bbbbbbbbbb…",
+				"    |      ^~~",
+			},
+		},
+		{
+			what: "right side",
+			opts: Options{Style: SnippetGutter, MaxWidth: 10},
+			code: long,
+			from: 2,
+			to:   4,
+			want: []string{
+				"  1 | aaaaaaaaa…",
+				"    |   ^~",
+			},
+		},
+		{
+			what: "left side",
+			opts: Options{Style: SnippetGutter, MaxWidth: 10},
+			code: long,
+			from: 85,
+			to:   87,
+			want: []string{
+				"  1 | …bbbbbbbbb",
+				"    |        ^~",
+			},
+		},
+		{
+			what: "range exceeds window",
+			opts: Options{Style: SnippetGutter, MaxWidth: 10},
+			code: long,
+			from: 40,
+			to:   70,
+			want: []string{
+				"  1 | …afoo(bar…",
+				"    |   ^~~~~~~",
+			},
+		},
+		{
+			what: "multi-byte characters",
+			opts: Options{Style: SnippetGutter, MaxWidth: 8},
+			code: "ああああああいいいいいい",
+			from: 18,
+			to:   24,
+			want: []string{
+				"  1 | … いい…",
+				"    |   ^~~~",
+			},
+		},
+		{
+			what: "multiple lines",
+			opts: Options{Style: SnippetGutter, MaxWidth: 10},
+			code: long + "\n" + long,
+			from: 44,
+			to:   135,
+			want: []string{
+				"  1 | …(bar)bbb…",
+				"    |   ^~~~~~~",
+				"  2 | …(bar)bbb…",
+				"    |  ~~~",
+			},
+		},
+		{
+			what: "oneline at end of line",
+			opts: Options{Style: SnippetGutter, MaxWidth: 10},
+			code: long,
+			from: 88,
+			to:   88,
+			want: []string{
+				"  1 | …bbbbbbbbb",
+				"    |           ^",
+			},
+		},
+		{
+			what: "offset in the middle of combining character",
+			opts: Options{Style: SnippetGutter, MaxWidth: 4},
+			code: "b\u3042a\ne\u0301\u3042be\u0301a",
+			from: 15,
+			to:   16,
+			want: []string{
+				"  2 | …be\u0301a",
+				"    |    ^",
+			},
+		},
+		{
+			what: "offset in the middle of combining character with prefix style",
+			opts: Options{MaxWidth: 4},
+			code: "b\u3042a\ne\u0301\u3042be\u0301a",
+			from: 15,
+			to:   16,
+			want: []string{
+				"> …be\u0301a",
+			},
+		},
+		{
+			what: "prefix style",
+			opts: Options{MaxWidth: 20},
+			code: long,
+			from: 44,
+			to:   47,
+			want: []string{
+				"> …foo(bar)bbbbbbbbbb…",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.what, func(t *testing.T) {
+			SetOptions(tc.opts)
+			src := NewDummySource(tc.code)
+			err := ErrorIn(src.PosAt(tc.from), src.PosAt(tc.to), "text")
+			have := strings.SplitN(err.Error(), "\n", 3)[2]
+			want := strings.Join(tc.want, "\n") + "\n"
+			if have != want {
+				t.Fatalf("Unexpected snippet\n\nwant:\n'%s'\nhave:\n'%s'", want, have)
+			}
+		})
+	}
+}
+
+func TestSnippetDisplayWidth(t *testing.T) {
+	defer func(saved Options) { options = saved }(options)
+
+	cases := []struct {
+		what string
+		opts Options
+		code string
+		from int
+		to   int
+		want []string
+	}{
+		{
+			what: "tab stop",
+			opts: Options{Style: SnippetGutter, TabWidth: 4},
+			code: "a\tb\tc",
+			from: 4,
+			to:   5,
+			want: []string{
+				"  1 | a   b   c",
+				"    |         ^",
+			},
+		},
+		{
+			what: "custom tab stop",
+			opts: Options{Style: SnippetGutter, TabWidth: 8},
+			code: "\tfoo(\tbar)",
+			from: 6,
+			to:   9,
+			want: []string{
+				"  1 |         foo(    bar)",
+				"    |                 ^~~",
+			},
+		},
+		{
+			what: "tab in range",
+			opts: Options{Style: SnippetGutter, TabWidth: 4},
+			code: "ab\tc",
+			from: 1,
+			to:   4,
+			want: []string{
+				"  1 | ab  c",
+				"    |  ^~~~",
+			},
+		},
+		{
+			what: "wide characters before range",
+			opts: Options{Style: SnippetGutter},
+			code: "漢字(ｶﾅ, \"😀\")",
+			from: 16,
+			to:   21,
+			want: []string{
+				"  1 | 漢字(ｶﾅ, \"😀\")",
+				"    |           ^~~",
+			},
+		},
+		{
+			what: "zero width characters before range",
+			opts: Options{Style: SnippetGutter},
+			code: "e\u0301e\u0301 = x",
+			from: 9,
+			to:   10,
+			want: []string{
+				"  1 | e\u0301e\u0301 = x",
+				"    |      ^",
+			},
+		},
+		{
+			what: "multiple lines with wide characters",
+			opts: Options{Style: SnippetGutter},
+			code: "あ(\n\tい)",
+			from: 3,
+			to:   9,
+			want: []string{
+				"  1 | あ(",
+				"    |   ^",
+				"  2 |     い)",
+				"    |     ~~",
+			},
+		},
+		{
+			what: "offsets in the middle of character after tab",
+			opts: Options{Style: SnippetGutter},
+			code: "\t\u00e9",
+			from: 1,
+			to:   2,
+			want: []string{
+				"  1 |     \u00e9",
+				"    |     ^",
+			},
+		},
+		{
+			what: "wide characters are cropped",
+			opts: Options{Style: SnippetGutter, MaxWidth: 10},
+			code: "ああああああ(x)いいいいいい",
+			from: 19,
+			to:   20,
+			want: []string{
+				"  1 | …(x)いい…",
+				"    |   ^",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.what, func(t *testing.T) {
+			SetOptions(tc.opts)
+			src := NewDummySource(tc.code)
+			err := ErrorIn(src.PosAt(tc.from), src.PosAt(tc.to), "text")
+			have := strings.SplitN(err.Error(), "\n", 3)[2]
+			want := strings.Join(tc.want, "\n") + "\n"
+			if have != want {
+				t.Fatalf("Unexpected snippet\n\nwant:\n'%s'\nhave:\n'%s'", want, have)
+			}
+		})
+	}
+}
@@ -4,10 +4,15 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 )
 
 // Source represents Dachs source code file. It may be a file on filesystem, stdin or dummy file.
+// Methods to look up lines (LineCount, LineStart, PosAt and PosAtLineCol) build an index of lines
+// lazily. They are safe to be called from multiple goroutines, but Code must not be modified after
+// the first call.
 type Source struct {
 	// Path of the file. <stdin> if it is stdin. <dummy> if it is a dummy source.
 	Path string
@@ -15,6 +20,10 @@ type Source struct {
 	Code []byte
 	// Exists indicates this source exists in filesystem or not.
 	Exists bool
+
+	// Offsets of the heads of lines. This is built lazily on first lookup
+	lines []int
+	once  sync.Once
 }
 
 // NewSourceFromFile make *Source object from file path.
@@ -27,7 +36,7 @@ func NewSourceFromFile(file string) (*Source, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Source{path, b, true}, nil
+	return &Source{Path: path, Code: b, Exists: true}, nil
 }
 
 // NewSourceFromStdin make *Source object from stdin. User will need to input source code into stdin.
@@ -36,12 +45,12 @@ func NewSourceFromStdin() (*Source, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Source{"<stdin>", b, false}, nil
+	return &Source{Path: "<stdin>", Code: b}, nil
 }
 
 // NewDummySource make *Source with passed code. The source is actually does not exist in filesystem (so dummy). This is used for tests.
 func NewDummySource(code string) *Source {
-	return &Source{"<dummy>", []byte(code), false}
+	return &Source{Path: "<dummy>", Code: []byte(code)}
 }
 
 // BaseName makes a base name from the name of source. If the source does not exist in filesystem, its base name will be 'out'.
@@ -56,3 +65,81 @@ func (src *Source) BaseName() string {
 func (src *Source) String() string {
 	return "source:" + src.Path
 }
+
+func (src *Source) lineIndex() []int {
+	src.once.Do(func() {
+		lines := []int{0}
+		for i, b := range src.Code {
+			if b == '\n' {
+				lines = append(lines, i+1)
+			}
+		}
+		src.lines = lines
+	})
+	return src.lines
+}
+
+// LineCount returns the number of lines in the source. Note that empty source has one line and
+// source ending with newline has an empty line at the end.
+func (src *Source) LineCount() int {
+	return len(src.lineIndex())
+}
+
+// LineStart returns the offset of the head of the line. Line number starts from 1. When the line
+// does not exist in the source, it returns -1.
+func (src *Source) LineStart(line int) int {
+	lines := src.lineIndex()
+	if line < 1 || len(lines) < line {
+		return -1
+	}
+	return lines[line-1]
+}
+
+// PosAt returns the position at the offset. Column in the returned position is in bytes and
+// starts from 1. Offset is clamped into the range of the source.
+func (src *Source) PosAt(offset int) Pos {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(src.Code) {
+		offset = len(src.Code)
+	}
+	lines := src.lineIndex()
+	// Index of the first line which starts after the offset
+	l := sort.SearchInts(lines, offset+1)
+	return Pos{
+		Offset: offset,
+		Line:   l,
+		Column: offset - lines[l-1] + 1,
+		File:   src,
+	}
+}
+
+// PosAtLineCol returns the position at the line and column. Both line and column start from 1 and
+// column is in bytes. They are clamped into the range of the source.
+func (src *Source) PosAtLineCol(line, col int) Pos {
+	lines := src.lineIndex()
+	if line < 1 {
+		line = 1
+	}
+	if line > len(lines) {
+		line = len(lines)
+	}
+	start := lines[line-1]
+	end := len(src.Code)
+	if line < len(lines) {
+		end = lines[line] - 1 // Exclude newline
+	}
+	if col < 1 {
+		col = 1
+	}
+	if col > end-start+1 {
+		col = end - start + 1
+	}
+	return Pos{
+		Offset: start + col - 1,
+		Line:   line,
+		Column: col,
+		File:   src,
+	}
+}
@@ -2,6 +2,7 @@ package locerr
 
 import (
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -82,3 +83,106 @@ func TestSourceString(t *testing.T) {
 		t.Fatal("Unknown source name:", s)
 	}
 }
+
+func TestLineIndex(t *testing.T) {
+	for _, tc := range []struct {
+		code   string
+		starts []int
+	}{
+		{"", []int{0}},
+		{"abc", []int{0}},
+		{"abc\n", []int{0, 4}},
+		{"abc\ndef", []int{0, 4}},
+		{"\n\n", []int{0, 1, 2}},
+		{"a\n\nb\nc", []int{0, 2, 3, 5}},
+	} {
+		s := NewDummySource(tc.code)
+		if s.LineCount() != len(tc.starts) {
+			t.Errorf("Line count of %q should be %d but %d", tc.code, len(tc.starts), s.LineCount())
+		}
+		for i, want := range tc.starts {
+			if have := s.LineStart(i + 1); have != want {
+				t.Errorf("Start of line %d in %q should be %d but %d", i+1, tc.code, want, have)
+			}
+		}
+		if s.LineStart(0) != -1 || s.LineStart(len(tc.starts)+1) != -1 {
+			t.Errorf("Line out of range should be -1 in %q", tc.code)
+		}
+	}
+}
+
+func TestPosAt(t *testing.T) {
+	s := NewDummySource("abc\n\nあい\nd")
+	for _, tc := range []struct {
+		offset int
+		line   int
+		col    int
+	}{
+		{0, 1, 1},
+		{2, 1, 3},
+		{3, 1, 4},
+		{4, 2, 1},
+		{5, 3, 1},
+		{8, 3, 4},
+		{11, 3, 7},
+		{12, 4, 1},
+		{13, 4, 2},
+		{-1, 1, 1},
+		{100, 4, 2},
+	} {
+		p := s.PosAt(tc.offset)
+		if p.Line != tc.line || p.Column != tc.col || p.File != s {
+			t.Errorf("Position at offset %d should be %d:%d but %d:%d", tc.offset, tc.line, tc.col, p.Line, p.Column)
+		}
+		if tc.offset >= 0 && tc.offset <= len(s.Code) && p.Offset != tc.offset {
+			t.Errorf("Offset should be %d but %d", tc.offset, p.Offset)
+		}
+	}
+}
+
+func TestPosAtLineCol(t *testing.T) {
+	s := NewDummySource("abc\n\nde")
+	for _, tc := range []struct {
+		line   int
+		col    int
+		offset int
+		want   string
+	}{
+		{1, 1, 0, "<dummy>:1:1"},
+		{1, 3, 2, "<dummy>:1:3"},
+		{1, 4, 3, "<dummy>:1:4"},
+		{1, 10, 3, "<dummy>:1:4"},
+		{2, 1, 4, "<dummy>:2:1"},
+		{2, 2, 4, "<dummy>:2:1"},
+		{3, 2, 6, "<dummy>:3:2"},
+		{3, 3, 7, "<dummy>:3:3"},
+		{0, 0, 0, "<dummy>:1:1"},
+		{4, 1, 5, "<dummy>:3:1"},
+	} {
+		p := s.PosAtLineCol(tc.line, tc.col)
+		if p.Offset != tc.offset || p.String() != tc.want {
+			t.Errorf("Position at %d:%d should be %s (offset %d) but %s (offset %d)", tc.line, tc.col, tc.want, tc.offset, p, p.Offset)
+		}
+		if p != s.PosAt(p.Offset) {
+			t.Errorf("Position at %d:%d does not match to position at offset %d", tc.line, tc.col, p.Offset)
+		}
+	}
+}
+
+func TestLineIndexConcurrently(t *testing.T) {
+	s := NewDummySource(strings.Repeat("abc\n", 100))
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if p := s.PosAt(i * 4); p.Line != i+1 {
+				t.Errorf("Line at %d should be %d but %d", i*4, i+1, p.Line)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if s.LineCount() != 101 {
+		t.Fatal("Unexpected line count:", s.LineCount())
+	}
+}
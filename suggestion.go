@@ -0,0 +1,79 @@
+package locerr
+
+import (
+	"fmt"
+	"io"
+)
+
+// Suggestion is a machine-applicable edit to fix an error. Code in Range is replaced with
+// Replacement. When Range is empty, Replacement is inserted at the start of the range. When
+// Replacement is empty, code in the range is deleted.
+type Suggestion struct {
+	// Range of code to be replaced. When its End.File is nil, the range is treated as an empty range
+	// at its Start.
+	Range Range
+	// Replacement is the code replacing the range.
+	Replacement string
+	// Description of the edit such as "add semicolon". When it is empty, a description is made from
+	// the edit.
+	Description string
+}
+
+// span returns the offsets of the range to be replaced.
+func (s *Suggestion) span() (int, int) {
+	start := s.Range.Start.Offset
+	if s.Range.End.File == nil || s.Range.End.Offset < start {
+		return start, start
+	}
+	return start, s.Range.End.Offset
+}
+
+func (s *Suggestion) description() string {
+	if s.Description != "" {
+		return s.Description
+	}
+	start, end := s.span()
+	switch {
+	case start == end:
+		return fmt.Sprintf("insert `%s`", s.Replacement)
+	case s.Replacement == "":
+		return "remove this"
+	default:
+		return fmt.Sprintf("replace with `%s`", s.Replacement)
+	}
+}
+
+// patched returns the positions of the replacement in the code patched by the suggestion. The
+// second return value is false when the suggestion cannot be applied to its source.
+func (s *Suggestion) patched() (Pos, Pos, bool) {
+	src := s.Range.Start.File
+	start, end := s.span()
+	if src == nil || start < 0 || len(src.Code) < end {
+		return Pos{}, Pos{}, false
+	}
+	code := make([]byte, 0, len(src.Code)-(end-start)+len(s.Replacement))
+	code = append(code, src.Code[:start]...)
+	code = append(code, s.Replacement...)
+	code = append(code, src.Code[end:]...)
+	p := &Source{Path: src.Path, Code: code, Exists: src.Exists}
+	return p.PosAt(start), p.PosAt(start + len(s.Replacement)), true
+}
+
+func (s *Suggestion) writeMessage(w io.Writer, opts *Options) {
+	// Help: {desc} (at {pos})
+	//
+	// > {patched snippet}
+	a := Annotation{Kind: AnnotationHelp, Message: s.description(), Start: s.Range.Start}
+	a.writeMessage(w)
+	if start, end, ok := s.patched(); ok {
+		writeSnippet(w, start, end, opts)
+	}
+}
+
+// Suggest attaches the edit to fix the error. Code in the range is replaced with the replacement.
+// The error message shows the description followed by the snippet of the patched code where the
+// replacement is highlighted. Multiple suggestions can be attached to one error.
+func (err *Error) Suggest(r Range, replacement, description string) *Error {
+	err.Suggestions = append(err.Suggestions, Suggestion{Range: r, Replacement: replacement, Description: description})
+	return err
+}
@@ -0,0 +1,89 @@
+package locerr
+
+import (
+	"testing"
+)
+
+func TestSuggestionMessage(t *testing.T) {
+	defer func(saved Options) { options = saved }(options)
+
+	src := NewDummySource("let x = 1\nfoo(a b)\n")
+	pos := src.PosAt(15)
+	header := "Error: missing comma (at <dummy>:2:6)\n\n> foo(a b)\n"
+
+	for _, tc := range []struct {
+		what  string
+		err   *Error
+		style SnippetStyle
+		want  string
+	}{
+		{
+			"insert",
+			ErrorAt(pos, "missing comma").Suggest(Range{Start: pos}, ",", ""),
+			SnippetPrefix,
+			header + "\n  Help: insert `,` (at <dummy>:2:6)\n\n> foo(a, b)\n",
+		},
+		{
+			"replace",
+			ErrorAt(pos, "missing comma").Suggest(Range{src.PosAt(14), src.PosAt(17)}, "a, b", "separate arguments with comma"),
+			SnippetPrefix,
+			header + "\n  Help: separate arguments with comma (at <dummy>:2:5)\n\n> foo(a, b)\n",
+		},
+		{
+			"delete",
+			ErrorAt(pos, "missing comma").Suggest(Range{src.PosAt(15), src.PosAt(17)}, "", ""),
+			SnippetPrefix,
+			header + "\n  Help: remove this (at <dummy>:2:6)\n\n> foo(a)\n",
+		},
+		{
+			"multiple suggestions",
+			ErrorAt(pos, "missing comma").
+				Suggest(Range{Start: pos}, ",", "").
+				Suggest(Range{src.PosAt(15), src.PosAt(17)}, "", ""),
+			SnippetPrefix,
+			header +
+				"\n  Help: insert `,` (at <dummy>:2:6)\n\n> foo(a, b)\n" +
+				"\n  Help: remove this (at <dummy>:2:6)\n\n> foo(a)\n",
+		},
+		{
+			"after notes",
+			ErrorAt(pos, "missing comma").Suggest(Range{Start: pos}, ",", "").Note("note"),
+			SnippetPrefix,
+			header + "\n  Note: note\n  Help: insert `,` (at <dummy>:2:6)\n\n> foo(a, b)\n",
+		},
+		{
+			"multiple lines",
+			ErrorAt(pos, "missing comma").Suggest(Range{src.PosAt(15), src.PosAt(16)}, ",\n    ", ""),
+			SnippetPrefix,
+			header + "\n  Help: replace with `,\n    ` (at <dummy>:2:6)\n\n> foo(a,\n>     b)\n",
+		},
+		{
+			"insert in gutter style",
+			ErrorAt(pos, "missing comma").Suggest(Range{Start: pos}, ",", ""),
+			SnippetGutter,
+			"Error: missing comma (at <dummy>:2:6)\n\n  2 | foo(a b)\n    |      ^\n" +
+				"\n  Help: insert `,` (at <dummy>:2:6)\n\n  2 | foo(a, b)\n    |      ^\n",
+		},
+		{
+			"replace in gutter style",
+			ErrorAt(pos, "missing comma").Suggest(Range{src.PosAt(14), src.PosAt(17)}, "a, b", ""),
+			SnippetGutter,
+			"Error: missing comma (at <dummy>:2:6)\n\n  2 | foo(a b)\n    |      ^\n" +
+				"\n  Help: replace with `a, b` (at <dummy>:2:5)\n\n  2 | foo(a, b)\n    |     ^~~~\n",
+		},
+		{
+			"without position",
+			NewError("missing comma").Suggest(Range{}, ",", ""),
+			SnippetPrefix,
+			"Error: missing comma\n  Help: insert `,`",
+		},
+	} {
+		t.Run(tc.what, func(t *testing.T) {
+			SetOptions(Options{Style: tc.style})
+			have := tc.err.Error()
+			if have != tc.want {
+				t.Fatalf("Unexpected error message.\nwant:\n'%s'\nhave:\n'%s'", tc.want, have)
+			}
+		})
+	}
+}
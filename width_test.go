@@ -0,0 +1,34 @@
+package locerr
+
+import (
+	"testing"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"abc", 3},
+		{"あいう", 6},
+		{"ｱｲｳ", 3}, // Halfwidth katakana
+		{"ＡＢＣ", 6}, // Fullwidth alphabets
+		{"漢字", 4},
+		{"한국어", 6},
+		{"\u1100\u1161\u11a8", 2}, // Hangul jamo
+		{"e\u0301", 1},            // Combining character
+		{"a\u200bb", 2},           // Zero width space
+		{"😀", 2},
+		{"\U0001F44D\U0001F3FD", 2}, // Emoji modifier
+		{"…", 1},                    // Ambiguous
+		{"\t", 1},
+		{"\x1b", 0},
+		{"\xff", 1}, // Invalid UTF-8
+		{"\U00020000", 2},
+	} {
+		if have := displayWidth([]byte(tc.text)); have != tc.want {
+			t.Errorf("Width of %q should be %d but %d", tc.text, tc.want, have)
+		}
+	}
+}